@@ -2,10 +2,13 @@ package glidecli
 
 import (
 	"github.com/common-fate/glide-cli/cmd/command"
+	"github.com/common-fate/glide-cli/cmd/command/aws"
 	"github.com/common-fate/glide-cli/cmd/command/bootstrap"
 	"github.com/common-fate/glide-cli/cmd/command/config"
 	"github.com/common-fate/glide-cli/cmd/command/handler"
 	"github.com/common-fate/glide-cli/cmd/command/provider"
+	"github.com/common-fate/glide-cli/cmd/command/registry"
+	"github.com/common-fate/glide-cli/cmd/command/request"
 	"github.com/common-fate/glide-cli/cmd/command/rules"
 	"github.com/common-fate/glide-cli/cmd/command/targetgroup"
 	"github.com/urfave/cli/v2"
@@ -19,9 +22,16 @@ var OSSSubCommand = cli.Command{
 	Subcommands: []*cli.Command{
 		&command.Login,
 		&command.Logout,
+		&command.Apply,
+		&command.Export,
 		&config.Command,
 		&rules.Command,
+		&request.Command,
+		&request.ReviewCommand,
+		&request.ExecCommand,
+		&aws.Command,
 		&provider.Command,
+		&registry.Command,
 		&targetgroup.Command,
 		&handler.Command,
 		mw.WithBeforeFuncs(&bootstrap.Command, mw.RequireAWSCredentials()),
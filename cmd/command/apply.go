@@ -0,0 +1,82 @@
+package command
+
+import (
+	"os"
+
+	"github.com/AlecAivazis/survey/v2"
+	"github.com/common-fate/clio"
+	"github.com/common-fate/clio/clierr"
+	"github.com/common-fate/glide-cli/pkg/client"
+	"github.com/common-fate/glide-cli/pkg/config"
+	"github.com/common-fate/glide-cli/pkg/spec"
+	"github.com/urfave/cli/v2"
+)
+
+var Apply = cli.Command{
+	Name:        "apply",
+	Usage:       "Create, update and delete Target Groups, Handlers and links to match a spec file",
+	Description: "Converge the Target Groups, Handlers and links registered in Common Fate with a TOML or YAML spec file. Target Groups, Handlers and links which are not in the spec file are deleted.",
+	Flags: []cli.Flag{
+		&cli.PathFlag{Name: "file", Aliases: []string{"f"}, Required: true, Usage: "The spec file to apply (.toml, .yaml or .yml)"},
+		&cli.BoolFlag{Name: "dry-run", Usage: "Print the planned changes without applying them"},
+		&cli.BoolFlag{Name: "confirm", Aliases: []string{"y"}, Usage: "Apply the changes without prompting for confirmation"},
+	},
+	Action: func(c *cli.Context) error {
+		ctx := c.Context
+
+		desired, err := spec.Load(c.Path("file"))
+		if err != nil {
+			return err
+		}
+
+		cfg, err := config.Load()
+		if err != nil {
+			return err
+		}
+
+		cf, err := client.FromConfig(ctx, cfg)
+		if err != nil {
+			return err
+		}
+
+		current, err := spec.Current(ctx, cf)
+		if err != nil {
+			return err
+		}
+
+		changes := spec.Plan(*desired, *current)
+		if len(changes) == 0 {
+			clio.Success("No changes: Common Fate matches the spec file")
+			return nil
+		}
+
+		err = spec.PrintPlan(os.Stdout, changes)
+		if err != nil {
+			return err
+		}
+
+		if c.Bool("dry-run") {
+			clio.Infof("Dry run: %d changes were not applied", len(changes))
+			return nil
+		}
+
+		if !c.Bool("confirm") {
+			var confirm bool
+			err = survey.AskOne(&survey.Confirm{Message: "Apply these changes?"}, &confirm)
+			if err != nil {
+				return err
+			}
+			if !confirm {
+				return clierr.New("Cancelled: no changes were applied")
+			}
+		}
+
+		err = spec.Apply(ctx, cf, changes)
+		if err != nil {
+			return err
+		}
+
+		clio.Successf("Applied %d changes", len(changes))
+		return nil
+	},
+}
@@ -0,0 +1,12 @@
+package aws
+
+import "github.com/urfave/cli/v2"
+
+var Command = cli.Command{
+	Name:        "aws",
+	Description: "Manage AWS configuration for Access Rules",
+	Usage:       "Manage AWS configuration for Access Rules",
+	Subcommands: []*cli.Command{
+		&GenerateProfilesCommand,
+	},
+}
@@ -0,0 +1,155 @@
+package aws
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/common-fate/awsconfigfile"
+	"github.com/common-fate/clio"
+	"github.com/common-fate/glide-cli/pkg/client"
+	"github.com/common-fate/glide-cli/pkg/config"
+	"github.com/common-fate/glide-cli/pkg/profilesource"
+	"github.com/pmezard/go-difflib/difflib"
+	"github.com/urfave/cli/v2"
+	"gopkg.in/ini.v1"
+)
+
+var GenerateProfilesCommand = cli.Command{
+	Name:        "generate-profiles",
+	Description: "Generate AWS profiles in ~/.aws/config for each account and permission set that you can request access to with Access Rules",
+	Usage:       "Generate AWS profiles from Access Rules",
+	Flags: []cli.Flag{
+		&cli.StringFlag{Name: "sso-start-url", Required: true, Usage: "The AWS SSO start URL (for example, 'https://example.awsapps.com/start')"},
+		&cli.StringFlag{Name: "sso-region", Required: true, Usage: "The AWS region that AWS SSO is configured in"},
+		&cli.StringFlag{Name: "config-file", Value: awsconfigfile.DefaultSharedConfigFilename(), Usage: "The AWS config file to update"},
+		&cli.StringFlag{Name: "profile-template", Value: awsconfigfile.DefaultProfileNameTemplate, Usage: "The Go template used to name profiles (fields: AccountName, AccountID, RoleName, SSORegion)"},
+		&cli.StringFlag{Name: "prefix", Usage: "A prefix to add to the name of each generated profile"},
+		&cli.BoolFlag{Name: "prune", Usage: "Remove previously generated profiles for the SSO start URL which no longer match an Access Rule"},
+		&cli.BoolFlag{Name: "no-credential-process", Usage: "Generate regular AWS SSO profiles rather than profiles using the Granted credential process"},
+		&cli.BoolFlag{Name: "dry-run", Usage: "Print the updated config file rather than writing it"},
+		&cli.BoolFlag{Name: "diff", Usage: "Print a diff of the changes to the config file"},
+		&cli.IntFlag{Name: "concurrency", Value: profilesource.DefaultConcurrency, Usage: "The number of Access Rules to fetch at the same time"},
+		&cli.BoolFlag{Name: "no-cache", Usage: "Fetch the details of every Access Rule rather than using cached results"},
+		&cli.DurationFlag{Name: "cache-ttl", Value: time.Hour, Usage: "How long cached Access Rule details are used for"},
+	},
+	Action: func(c *cli.Context) error {
+		ctx := c.Context
+
+		cfg, err := config.Load()
+		if err != nil {
+			return err
+		}
+
+		current, err := cfg.Current()
+		if err != nil {
+			return err
+		}
+
+		cf, err := client.FromConfig(ctx, cfg)
+		if err != nil {
+			return err
+		}
+
+		configFile := c.String("config-file")
+
+		existing, err := os.ReadFile(configFile)
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+
+		awsConfig, err := ini.LoadSources(ini.LoadOptions{
+			AllowNonUniqueSections:  false,
+			SkipUnrecognizableLines: false,
+			AllowNestedValues:       true,
+		}, existing)
+		if err != nil {
+			return err
+		}
+
+		startURL := c.String("sso-start-url")
+
+		g := awsconfigfile.Generator{
+			Config:              awsConfig,
+			NoCredentialProcess: c.Bool("no-credential-process"),
+			ProfileNameTemplate: c.String("profile-template"),
+			Prefix:              c.String("prefix"),
+		}
+
+		if c.Bool("prune") {
+			g.PruneStartURLs = []string{startURL}
+		}
+
+		src := profilesource.Source{
+			SSORegion:    c.String("sso-region"),
+			StartURL:     startURL,
+			Client:       cf,
+			DashboardURL: current.DashboardURL,
+			Concurrency:  c.Int("concurrency"),
+		}
+
+		if !c.Bool("no-cache") {
+			cachePath, err := profilesource.DefaultCachePath(cfg.CurrentContext)
+			if err != nil {
+				return err
+			}
+			src.Cache = profilesource.NewCache(cachePath, c.Duration("cache-ttl"))
+		}
+
+		g.AddSource(src)
+
+		clio.Info("Generating profiles from Access Rules...")
+
+		err = g.Generate(ctx)
+		if err != nil {
+			return err
+		}
+
+		var updated bytes.Buffer
+		_, err = awsConfig.WriteTo(&updated)
+		if err != nil {
+			return err
+		}
+
+		if c.Bool("diff") {
+			diff, err := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+				A:        difflib.SplitLines(string(existing)),
+				B:        difflib.SplitLines(updated.String()),
+				FromFile: configFile,
+				ToFile:   configFile,
+				Context:  3,
+			})
+			if err != nil {
+				return err
+			}
+			if diff == "" {
+				clio.Info("No changes to the AWS config file")
+			}
+			fmt.Print(diff)
+		}
+
+		if c.Bool("dry-run") {
+			if !c.Bool("diff") {
+				fmt.Print(updated.String())
+			}
+			clio.Infof("Dry run: %s was not updated", configFile)
+			return nil
+		}
+
+		err = os.MkdirAll(filepath.Dir(configFile), 0700)
+		if err != nil {
+			return err
+		}
+
+		err = os.WriteFile(configFile, updated.Bytes(), 0600)
+		if err != nil {
+			return err
+		}
+
+		clio.Successf("Updated AWS profiles in %s", configFile)
+
+		return nil
+	},
+}
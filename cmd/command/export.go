@@ -0,0 +1,85 @@
+package command
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+
+	"github.com/common-fate/clio"
+	"github.com/common-fate/clio/clierr"
+	"github.com/common-fate/glide-cli/pkg/client"
+	"github.com/common-fate/glide-cli/pkg/config"
+	"github.com/common-fate/glide-cli/pkg/spec"
+	"github.com/urfave/cli/v2"
+)
+
+var Export = cli.Command{
+	Name:        "export",
+	Usage:       "Export Target Groups, Handlers and links to a spec file",
+	Description: "Write the Target Groups, Handlers and links registered in Common Fate to a spec file which can be used with 'cf apply'. If no file is provided, the spec is printed to stdout.",
+	Flags: []cli.Flag{
+		&cli.PathFlag{Name: "file", Aliases: []string{"f"}, Usage: "The spec file to write (.toml, .yaml or .yml)"},
+		&cli.StringFlag{Name: "format", Value: string(spec.TOML), Usage: "The format to print the spec in when no file is provided (toml or yaml)"},
+		&cli.BoolFlag{Name: "force", Usage: "Overwrite the spec file if it already exists"},
+	},
+	Action: func(c *cli.Context) error {
+		ctx := c.Context
+
+		file := c.Path("file")
+
+		format := spec.Format(c.String("format"))
+		if file != "" {
+			format = spec.FormatFromPath(file)
+		}
+		if format != spec.TOML && format != spec.YAML {
+			return clierr.New(fmt.Sprintf("Unsupported format '%s'.", format), clierr.Info("Supported formats: toml, yaml"))
+		}
+
+		if file != "" && !c.Bool("force") {
+			if _, err := os.Stat(file); err == nil {
+				return clierr.New(fmt.Sprintf("%s already exists.", file), clierr.Info("Use --force to overwrite it"))
+			}
+		}
+
+		cfg, err := config.Load()
+		if err != nil {
+			return err
+		}
+
+		cf, err := client.FromConfig(ctx, cfg)
+		if err != nil {
+			return err
+		}
+
+		current, err := spec.Current(ctx, cf)
+		if err != nil {
+			return err
+		}
+
+		// routes may refer to Handlers which have been deleted, which 'cf apply'
+		// won't accept, so let the user know to fix these up by hand.
+		err = current.Validate()
+		if err != nil {
+			clio.Warnf("The exported spec is not valid and will need to be edited before it can be applied: %s", err)
+		}
+
+		var buf bytes.Buffer
+		err = spec.Write(&buf, format, *current)
+		if err != nil {
+			return err
+		}
+
+		if file == "" {
+			fmt.Print(buf.String())
+			return nil
+		}
+
+		err = os.WriteFile(file, buf.Bytes(), 0600)
+		if err != nil {
+			return err
+		}
+
+		clio.Successf("Exported %d Target Groups, %d Handlers and %d links to %s", len(current.TargetGroups), len(current.Handlers), len(current.Links), file)
+		return nil
+	},
+}
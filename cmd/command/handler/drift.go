@@ -0,0 +1,261 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudformation"
+	cfntypes "github.com/aws/aws-sdk-go-v2/service/cloudformation/types"
+	"github.com/common-fate/clio"
+	"github.com/common-fate/clio/clierr"
+	"github.com/common-fate/common-fate/pkg/types"
+	"github.com/common-fate/glide-cli/cmd/middleware"
+	"github.com/common-fate/glide-cli/pkg/client"
+	"github.com/common-fate/glide-cli/pkg/config"
+	"github.com/common-fate/glide-cli/pkg/handlerstack"
+	"github.com/common-fate/glide-cli/pkg/table"
+	"github.com/common-fate/provider-registry-sdk-go/pkg/handlerclient"
+	"github.com/common-fate/provider-registry-sdk-go/pkg/providerregistrysdk"
+	"github.com/pkg/errors"
+	"github.com/urfave/cli/v2"
+)
+
+// handlerStackPrefix is the prefix of CloudFormation stacks created by 'cf provider deploy'.
+const handlerStackPrefix = "cf-handler-"
+
+const (
+	issueMissingStack    = "missing stack"
+	issueStackFailed     = "stack failed"
+	issueOrphanedStack   = "orphaned stack"
+	issueVersionMismatch = "version mismatch"
+	issueDescribeFailed  = "describe failed"
+)
+
+// driftIssue is a difference between a Handler registered
+// in Common Fate and its CloudFormation stack.
+type driftIssue struct {
+	HandlerID string
+	Account   string
+	Region    string
+	Issue     string
+	Details   string
+}
+
+var DriftCommand = cli.Command{
+	Name:        "drift",
+	Description: "Compare the Handlers registered in Common Fate with their CloudFormation stacks, reporting missing stacks, orphaned 'cf-handler-*' stacks, failed stacks and provider version mismatches. Only Handlers in the AWS account of your current credentials are checked.",
+	Usage:       "Detect drift between Handlers and CloudFormation stacks",
+	Action: func(c *cli.Context) error {
+		ctx := c.Context
+
+		awsContext, err := middleware.AWSContextFromContext(ctx)
+		if err != nil {
+			return err
+		}
+
+		account, err := awsContext.Account(ctx)
+		if err != nil {
+			return err
+		}
+
+		cfg, err := config.Load()
+		if err != nil {
+			return err
+		}
+
+		cf, err := client.FromConfig(ctx, cfg)
+		if err != nil {
+			return err
+		}
+
+		handlers, err := cf.AdminListHandlersWithResponse(ctx)
+		if err != nil {
+			return err
+		}
+
+		linked, err := linkedTargetGroups(ctx, cf)
+		if err != nil {
+			return err
+		}
+
+		var issues []driftIssue
+
+		// registered tracks the handlers in each region, to find orphaned stacks.
+		registered := map[string]map[string]bool{
+			awsContext.Config.Region: {},
+		}
+
+		for _, h := range handlers.JSON200.Res {
+			if h.AwsAccount != account {
+				clio.Warnf("Skipping Handler '%s': it is deployed to account %s but your AWS credentials are for account %s", h.Id, h.AwsAccount, account)
+				continue
+			}
+
+			if registered[h.AwsRegion] == nil {
+				registered[h.AwsRegion] = map[string]bool{}
+			}
+			registered[h.AwsRegion][h.Id] = true
+
+			awsCfg := awsContext.Config.Copy()
+			awsCfg.Region = h.AwsRegion
+
+			clio.Debugw("checking handler for drift", "handler", h.Id, "region", h.AwsRegion)
+
+			handlerIssues, err := checkHandler(ctx, awsCfg, h, linked[h.Id])
+			if err != nil {
+				return err
+			}
+			issues = append(issues, handlerIssues...)
+		}
+
+		for region, ids := range registered {
+			awsCfg := awsContext.Config.Copy()
+			awsCfg.Region = region
+
+			orphaned, err := orphanedStacks(ctx, awsCfg, ids)
+			if err != nil {
+				return err
+			}
+			for _, stack := range orphaned {
+				issues = append(issues, driftIssue{
+					HandlerID: stack,
+					Account:   account,
+					Region:    region,
+					Issue:     issueOrphanedStack,
+					Details:   "the stack is not registered as a Handler in Common Fate",
+				})
+			}
+		}
+
+		if len(issues) == 0 {
+			clio.Success("No drift detected")
+			return nil
+		}
+
+		sort.SliceStable(issues, func(i, j int) bool {
+			return issues[i].HandlerID < issues[j].HandlerID
+		})
+
+		w := table.New(os.Stdout)
+		w.Columns("HANDLER", "ACCOUNT", "REGION", "ISSUE", "DETAILS")
+		for _, i := range issues {
+			w.Row(i.HandlerID, i.Account, i.Region, i.Issue, i.Details)
+		}
+		err = w.Flush()
+		if err != nil {
+			return err
+		}
+
+		return clierr.New(fmt.Sprintf("Detected %d drift issues", len(issues)))
+	},
+}
+
+// checkHandler compares a Handler with its CloudFormation stack and deployed provider.
+func checkHandler(ctx context.Context, cfg aws.Config, h types.TGHandler, targetGroups []types.TargetGroup) ([]driftIssue, error) {
+	issue := func(kind, details string) driftIssue {
+		return driftIssue{HandlerID: h.Id, Account: h.AwsAccount, Region: h.AwsRegion, Issue: kind, Details: details}
+	}
+
+	var issues []driftIssue
+
+	cfnClient := cloudformation.NewFromConfig(cfg)
+	res, err := cfnClient.DescribeStacks(ctx, &cloudformation.DescribeStacksInput{
+		StackName: aws.String(h.Id),
+	})
+	// the CloudFormation API returns a generic validation error if the stack doesn't exist
+	if err != nil && strings.Contains(err.Error(), "does not exist") {
+		return []driftIssue{issue(issueMissingStack, fmt.Sprintf("stack '%s' was not found", h.Id))}, nil
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "describing stack %s", h.Id)
+	}
+	if len(res.Stacks) == 0 {
+		return []driftIssue{issue(issueMissingStack, fmt.Sprintf("stack '%s' was not found", h.Id))}, nil
+	}
+
+	stack := res.Stacks[0]
+	if stackFailed(stack.StackStatus) {
+		issues = append(issues, issue(issueStackFailed, fmt.Sprintf("stack is in %s state", stack.StackStatus)))
+	}
+
+	desc, err := handlerclient.NewLambdaRuntimeFromConfig(cfg, h.Id).Describe(ctx)
+	if err != nil {
+		return append(issues, issue(issueDescribeFailed, err.Error())), nil
+	}
+	deployed := desc.Provider.String()
+
+	for _, p := range stack.Parameters {
+		if aws.ToString(p.ParameterKey) != handlerstack.ParamAssetPath {
+			continue
+		}
+		stackProvider, ok := handlerstack.ProviderFromAssetPath(aws.ToString(p.ParameterValue))
+		if ok && stackProvider.String() != deployed {
+			issues = append(issues, issue(issueVersionMismatch, fmt.Sprintf("stack deploys %s but the Handler reports %s", stackProvider, deployed)))
+		}
+	}
+
+	for _, tg := range targetGroups {
+		from := providerregistrysdk.Provider{Publisher: tg.From.Publisher, Name: tg.From.Name, Version: tg.From.Version}
+		if from.String() != deployed {
+			issues = append(issues, issue(issueVersionMismatch, fmt.Sprintf("Target Group '%s' uses %s but the Handler reports %s", tg.Id, from, deployed)))
+		}
+	}
+
+	return issues, nil
+}
+
+// linkedTargetGroups returns the Target Groups linked to each Handler, keyed by Handler ID.
+func linkedTargetGroups(ctx context.Context, cf *client.Client) (map[string][]types.TargetGroup, error) {
+	tgs, err := cf.AdminListTargetGroupsWithResponse(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	linked := map[string][]types.TargetGroup{}
+	for _, tg := range tgs.JSON200.TargetGroups {
+		routes, err := cf.AdminListTargetRoutesWithResponse(ctx, tg.Id)
+		if err != nil {
+			return nil, err
+		}
+		for _, r := range routes.JSON200.Routes {
+			linked[r.HandlerId] = append(linked[r.HandlerId], tg)
+		}
+	}
+	return linked, nil
+}
+
+// orphanedStacks returns the names of Handler stacks in a region
+// which aren't registered as a Handler in Common Fate.
+func orphanedStacks(ctx context.Context, cfg aws.Config, registered map[string]bool) ([]string, error) {
+	var orphaned []string
+
+	p := cloudformation.NewListStacksPaginator(cloudformation.NewFromConfig(cfg), &cloudformation.ListStacksInput{})
+	for p.HasMorePages() {
+		page, err := p.NextPage(ctx)
+		if err != nil {
+			return nil, errors.Wrapf(err, "listing stacks in %s", cfg.Region)
+		}
+		for _, s := range page.StackSummaries {
+			name := aws.ToString(s.StackName)
+			if s.StackStatus == cfntypes.StackStatusDeleteComplete || !strings.HasPrefix(name, handlerStackPrefix) {
+				continue
+			}
+			if !registered[name] {
+				orphaned = append(orphaned, name)
+			}
+		}
+	}
+
+	return orphaned, nil
+}
+
+// stackFailed returns true if a stack failed to deploy, or if its last update was rolled back.
+func stackFailed(status cfntypes.StackStatus) bool {
+	return strings.HasSuffix(string(status), "_FAILED") ||
+		status == cfntypes.StackStatusRollbackComplete ||
+		status == cfntypes.StackStatusUpdateRollbackComplete
+}
@@ -6,6 +6,7 @@ import (
 	"github.com/AlecAivazis/survey/v2"
 	"github.com/common-fate/clio"
 	"github.com/common-fate/common-fate/pkg/types"
+	mw "github.com/common-fate/glide-cli/cmd/middleware"
 	"github.com/common-fate/glide-cli/pkg/client"
 	"github.com/common-fate/glide-cli/pkg/config"
 	"github.com/urfave/cli/v2"
@@ -22,6 +23,8 @@ var Command = cli.Command{
 		&DiagnosticCommand,
 		&LogsCommand,
 		&DeleteCommand,
+		mw.WithBeforeFuncs(&DriftCommand, mw.RequireAWSCredentials()),
+		&SecretsCommand,
 	},
 }
 
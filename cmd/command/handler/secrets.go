@@ -0,0 +1,306 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/AlecAivazis/survey/v2"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudformation"
+	"github.com/common-fate/clio"
+	"github.com/common-fate/clio/clierr"
+	mw "github.com/common-fate/glide-cli/cmd/middleware"
+	"github.com/common-fate/glide-cli/pkg/client"
+	"github.com/common-fate/glide-cli/pkg/config"
+	"github.com/common-fate/glide-cli/pkg/fmtconvert"
+	"github.com/common-fate/glide-cli/pkg/handlerstack"
+	"github.com/common-fate/glide-cli/pkg/secrets"
+	"github.com/common-fate/glide-cli/pkg/ssmkey"
+	"github.com/common-fate/glide-cli/pkg/table"
+	"github.com/common-fate/glide-cli/pkg/values"
+	"github.com/pkg/errors"
+	"github.com/urfave/cli/v2"
+)
+
+var SecretsCommand = cli.Command{
+	Name:        "secrets",
+	Description: "Manage the secret config values of a deployed Handler. Secret values are never printed.",
+	Usage:       "Manage the secret config values of a Handler",
+	Subcommands: []*cli.Command{
+		mw.WithBeforeFuncs(&secretsListCommand, mw.RequireAWSCredentials()),
+		mw.WithBeforeFuncs(&secretsSetCommand, mw.RequireAWSCredentials()),
+		mw.WithBeforeFuncs(&secretsVerifyCommand, mw.RequireAWSCredentials()),
+	},
+}
+
+var secretsListCommand = cli.Command{
+	Name:        "list",
+	Aliases:     []string{"ls"},
+	Description: "List the secrets referenced by a Handler's CloudFormation stack, and any other SSM parameters stored under the Handler's secret path. Secret values are never read.",
+	Usage:       "List the secrets of a Handler",
+	Flags: []cli.Flag{
+		&cli.StringFlag{Name: "handler-id", Required: true, Usage: "The ID of the Handler"},
+	},
+	Action: func(c *cli.Context) error {
+		ctx := c.Context
+
+		hs, err := loadHandlerSecrets(ctx, c.String("handler-id"))
+		if err != nil {
+			return err
+		}
+
+		w := table.New(os.Stdout)
+		w.Columns("PARAMETER", "NAME", "LAST MODIFIED", "STATUS")
+
+		referenced := map[string]bool{}
+		for _, param := range hs.paramNames() {
+			info, err := secrets.Lookup(ctx, hs.awsCfg, hs.secretParams[param])
+			if err != nil {
+				w.Row(param, hs.secretParams[param], "-", err.Error())
+				continue
+			}
+			referenced[info.Reference()] = true
+			w.Row(param, info.Name, formatLastModified(info), secretStatus(info))
+		}
+
+		// parameters under the Handler's secret path which aren't referenced by the stack
+		// may have been left behind by previous deployments.
+		if hs.prefix != "" {
+			stored, err := secrets.ListSSM(ctx, hs.awsCfg, hs.prefix)
+			if err != nil {
+				return err
+			}
+			for _, info := range stored {
+				if referenced[info.Reference()] {
+					continue
+				}
+				w.Row("-", info.Name, formatLastModified(info), "not referenced by stack")
+			}
+		}
+
+		return w.Flush()
+	},
+}
+
+var secretsSetCommand = cli.Command{
+	Name:        "set",
+	Description: "Set or rotate a secret config value of a Handler. The new value is written to the secret referenced by the Handler's CloudFormation stack, keeping its KMS key. If no value source is given, you will be prompted for the value.",
+	Usage:       "Set or rotate a secret of a Handler",
+	Flags: []cli.Flag{
+		&cli.StringFlag{Name: "handler-id", Required: true, Usage: "The ID of the Handler"},
+		&cli.StringFlag{Name: "key", Required: true, Usage: "The config key (for example, 'apiToken') or CloudFormation parameter (for example, 'ApiTokenSecret') of the secret"},
+		&cli.StringFlag{Name: "from-env", Usage: "Read the secret value from an environment variable"},
+		&cli.PathFlag{Name: "from-file", Usage: "Read the secret value from a file"},
+	},
+	Action: func(c *cli.Context) error {
+		ctx := c.Context
+
+		if c.IsSet("from-env") && c.IsSet("from-file") {
+			return clierr.New("Only one of --from-env and --from-file can be used.")
+		}
+
+		hs, err := loadHandlerSecrets(ctx, c.String("handler-id"))
+		if err != nil {
+			return err
+		}
+
+		key := c.String("key")
+		param := key
+		if _, ok := hs.secretParams[param]; !ok {
+			param = fmtconvert.PascalCase(key) + "Secret"
+		}
+		ref, ok := hs.secretParams[param]
+		if !ok {
+			return clierr.New(fmt.Sprintf("Handler '%s' doesn't have a secret config value '%s'.", hs.handlerID, key), clierr.Infof("The secret parameters of the Handler's stack are: %s", strings.Join(hs.paramNames(), ", ")))
+		}
+
+		info, err := secrets.Lookup(ctx, hs.awsCfg, ref)
+		if err != nil {
+			return err
+		}
+
+		var secret string
+		if c.IsSet("from-env") || c.IsSet("from-file") {
+			cv := values.ConfigValue{FromEnv: c.String("from-env"), FromFile: c.Path("from-file")}
+			secret, err = cv.Resolve()
+			if err != nil {
+				return err
+			}
+		} else {
+			err = survey.AskOne(&survey.Password{Message: key + ":"}, &secret, survey.WithValidator(survey.Required))
+			if err != nil {
+				return err
+			}
+		}
+
+		w := secrets.WriterFor(hs.awsCfg, info)
+		err = w.Write(ctx, info.Name, secret)
+		if err != nil {
+			return err
+		}
+
+		if info.Exists {
+			clio.Successf("Updated %s", w.Description(info.Name))
+		} else {
+			clio.Successf("Added to %s", w.Description(info.Name))
+		}
+		return nil
+	},
+}
+
+var secretsVerifyCommand = cli.Command{
+	Name:        "verify",
+	Description: "Check that every secret referenced by the '*Secret' parameters of a Handler's CloudFormation stack exists.",
+	Usage:       "Check that the secrets of a Handler exist",
+	Flags: []cli.Flag{
+		&cli.StringFlag{Name: "handler-id", Required: true, Usage: "The ID of the Handler"},
+	},
+	Action: func(c *cli.Context) error {
+		ctx := c.Context
+
+		hs, err := loadHandlerSecrets(ctx, c.String("handler-id"))
+		if err != nil {
+			return err
+		}
+
+		if len(hs.secretParams) == 0 {
+			clio.Infof("Handler '%s' doesn't have any secret config values", hs.handlerID)
+			return nil
+		}
+
+		w := table.New(os.Stdout)
+		w.Columns("PARAMETER", "REFERENCE", "STATUS")
+
+		var problems int
+		for _, param := range hs.paramNames() {
+			ref := hs.secretParams[param]
+			var status string
+			info, err := secrets.Lookup(ctx, hs.awsCfg, ref)
+			if err != nil {
+				status = err.Error()
+			} else {
+				status = secretStatus(info)
+			}
+			if status != "ok" {
+				problems++
+			}
+			w.Row(param, ref, status)
+		}
+
+		err = w.Flush()
+		if err != nil {
+			return err
+		}
+
+		if problems > 0 {
+			return clierr.New(fmt.Sprintf("%d of the secrets referenced by Handler '%s' can't be read.", problems, hs.handlerID), clierr.Infof("Run 'cf handler secrets set --handler-id %s --key <parameter>' to set a missing secret", hs.handlerID))
+		}
+
+		clio.Successf("All secrets referenced by Handler '%s' exist", hs.handlerID)
+		return nil
+	},
+}
+
+// handlerSecrets are the secret parameters of a Handler's CloudFormation stack.
+type handlerSecrets struct {
+	handlerID string
+	// awsCfg uses the region the Handler is deployed to.
+	awsCfg aws.Config
+	// secretParams maps the stack's secret parameter names to secret references.
+	secretParams map[string]string
+	// prefix is the SSM path the Handler's secrets are stored under by default.
+	// It is empty if the provider deployed by the stack can't be determined.
+	prefix string
+}
+
+// paramNames returns the sorted names of the secret parameters.
+func (hs handlerSecrets) paramNames() []string {
+	var names []string
+	for k := range hs.secretParams {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// loadHandlerSecrets reads the secret parameters from the CloudFormation stack of a Handler.
+func loadHandlerSecrets(ctx context.Context, handlerID string) (*handlerSecrets, error) {
+	awsContext, err := mw.AWSContextFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		return nil, err
+	}
+
+	cf, err := client.FromConfig(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	handler, err := cf.AdminGetHandlerWithResponse(ctx, handlerID)
+	if err != nil {
+		return nil, errors.Wrapf(err, "getting Handler %s", handlerID)
+	}
+
+	account, err := awsContext.Account(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if handler.JSON200.AwsAccount != account {
+		return nil, clierr.New(fmt.Sprintf("Handler '%s' is deployed to account %s, but your AWS credentials are for account %s.", handlerID, handler.JSON200.AwsAccount, account), clierr.Info("Export credentials for the Handler's account and try again"))
+	}
+
+	// the stack is deployed in the region that the Handler is registered with,
+	// which may be different to the region of the current AWS credentials.
+	awsCfg := awsContext.Config.Copy()
+	awsCfg.Region = handler.JSON200.AwsRegion
+
+	stacks, err := cloudformation.NewFromConfig(awsCfg).DescribeStacks(ctx, &cloudformation.DescribeStacksInput{
+		StackName: &handlerID,
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "describing CloudFormation stack %s", handlerID)
+	}
+	if len(stacks.Stacks) == 0 {
+		return nil, fmt.Errorf("could not find stack %s", handlerID)
+	}
+
+	params := map[string]string{}
+	for _, p := range stacks.Stacks[0].Parameters {
+		params[aws.ToString(p.ParameterKey)] = aws.ToString(p.ParameterValue)
+	}
+
+	hs := handlerSecrets{
+		handlerID:    handlerID,
+		awsCfg:       awsCfg,
+		secretParams: handlerstack.SecretParameters(params),
+	}
+
+	if p, ok := handlerstack.ProviderFromAssetPath(params[handlerstack.ParamAssetPath]); ok {
+		hs.prefix = ssmkey.Prefix(p.Publisher, p.Name, handlerID)
+	}
+
+	return &hs, nil
+}
+
+func secretStatus(info secrets.Info) string {
+	if !info.Exists {
+		return "missing"
+	}
+	return "ok"
+}
+
+func formatLastModified(info secrets.Info) string {
+	if !info.Exists || info.LastModified.IsZero() {
+		return "-"
+	}
+	return info.LastModified.Local().Format(time.RFC3339)
+}
@@ -3,29 +3,34 @@ package provider
 import (
 	"context"
 	"fmt"
-	"path"
 	"sort"
 	"strings"
 	"time"
 
 	"github.com/AlecAivazis/survey/v2"
 	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudformation"
 	"github.com/aws/aws-sdk-go-v2/service/cloudformation/types"
 	"github.com/aws/aws-sdk-go-v2/service/iam"
 	iamTypes "github.com/aws/aws-sdk-go-v2/service/iam/types"
-	"github.com/aws/aws-sdk-go-v2/service/ssm"
-	ssmTypes "github.com/aws/aws-sdk-go-v2/service/ssm/types"
 	"github.com/common-fate/clio"
+	"github.com/common-fate/clio/clierr"
+	"github.com/common-fate/cloudform/cfn"
 	"github.com/common-fate/cloudform/deployer"
 	"github.com/common-fate/glide-cli/cmd/middleware"
 	"github.com/pkg/errors"
 
-	cftypes "github.com/common-fate/common-fate/pkg/types"
 	"github.com/common-fate/glide-cli/pkg/client"
 	cfconfig "github.com/common-fate/glide-cli/pkg/config"
+	"github.com/common-fate/glide-cli/pkg/deployment"
 	"github.com/common-fate/glide-cli/pkg/fmtconvert"
+	"github.com/common-fate/glide-cli/pkg/handlerstack"
 	"github.com/common-fate/glide-cli/pkg/prompt"
+	"github.com/common-fate/glide-cli/pkg/providerconfig"
+	"github.com/common-fate/glide-cli/pkg/secrets"
+	"github.com/common-fate/glide-cli/pkg/spec"
 	"github.com/common-fate/glide-cli/pkg/ssmkey"
+	"github.com/common-fate/glide-cli/pkg/values"
 	"github.com/common-fate/provider-registry-sdk-go/pkg/bootstrapper"
 	"github.com/common-fate/provider-registry-sdk-go/pkg/providerregistrysdk"
 	registryclient "github.com/common-fate/provider-registry-sdk-go/pkg/registryclient"
@@ -37,7 +42,7 @@ var deployCommand = cli.Command{
 	Name:        "deploy",
 	Description: "Quickstart command to deploy a provider",
 	Usage:       "Quickstart command to deploy a provider",
-	Flags: []cli.Flag{
+	Flags: append([]cli.Flag{
 		&cli.StringFlag{Name: "provider", Aliases: []string{"p"}, Usage: "The provider to deploy (for example, 'common-fate/aws@v0.4.0')"},
 		&cli.StringFlag{Name: "handler-id", Usage: "The Handler ID and CloudFormation stack name to use (by convention, this is 'cf-handler-[provider publisher]-[provider name]')"},
 		&cli.StringFlag{Name: "target-group-id", Usage: "Override the ID of the Target Group which will be created"},
@@ -45,7 +50,12 @@ var deployCommand = cli.Command{
 		&cli.StringFlag{Name: "target", Aliases: []string{"t"}, Usage: "The target kind to use with the provider (only required if the provider grants access to multiple kinds of targets)"},
 		&cli.BoolFlag{Name: "confirm-bootstrap", Usage: "Confirm creating a bootstrap bucket if it doesn't exist in the account and region you are deploying to"},
 		&cli.StringSliceFlag{Name: "config", Usage: "Provide config values for the provider in key=value format"},
-	},
+		&cli.BoolFlag{Name: "dry-run", Usage: "Print the CloudFormation parameters, template and Common Fate changes without deploying the provider"},
+		&cli.BoolFlag{Name: "change-set", Usage: "With --dry-run, create a CloudFormation change set for review without executing it (provider assets are copied to the bootstrap bucket if they are missing)"},
+		&cli.BoolFlag{Name: "rollback-on-failure", Usage: "If the deployment fails, remove the Common Fate resources and CloudFormation stack created by the deployment"},
+		&cli.StringFlag{Name: "resume", Usage: "Resume an interrupted deployment of the Handler with the given ID, from the deployment record in ~/.commonfate/deployments"},
+		&cli.PathFlag{Name: "values-file", Usage: "A YAML file containing the deployment values and provider config. When provided, the command runs without prompting and fails if a value is missing"},
+	}, secrets.Flags()...),
 	Action: func(c *cli.Context) error {
 		ctx := c.Context
 
@@ -66,10 +76,40 @@ var deployCommand = cli.Command{
 			return errors.Wrap(err, "configuring provider registry client")
 		}
 
+		dryRun := c.Bool("dry-run")
+		if c.Bool("change-set") && !dryRun {
+			return clierr.New("The --change-set flag can only be used with --dry-run.")
+		}
+
+		if resumeID := c.String("resume"); resumeID != "" {
+			if dryRun {
+				return clierr.New("The --resume flag can't be used with --dry-run.")
+			}
+			return resumeDeployment(ctx, awsContext, registry, resumeID, c.Bool("rollback-on-failure"))
+		}
+
+		// when a values file is provided, the deployment runs non-interactively.
+		// Values provided as flags take precedence over the values file.
+		vals := &values.Values{}
+		valuesFile := c.Path("values-file")
+		interactive := valuesFile == ""
+		if valuesFile != "" {
+			vals, err = values.Load(valuesFile)
+			if err != nil {
+				return err
+			}
+		}
+
+		secretOpts := secrets.OptsFromFlags(c)
+		if secretOpts.KMSKeyID == "" {
+			secretOpts.KMSKeyID = vals.Secrets.KMSKeyID
+		}
+		secretWriter := secrets.New(awsContext.Config, secretOpts)
+
 		var provider *providerregistrysdk.ProviderDetail
 
 		// validate this as early as possible
-		providerArg := c.String("provider")
+		providerArg := flagOrValue(c, "provider", vals.Provider)
 		if providerArg != "" {
 			p, err := providerregistrysdk.ParseProvider(providerArg)
 			if err != nil {
@@ -83,18 +123,17 @@ var deployCommand = cli.Command{
 			provider = res.JSON200
 		}
 
-		configArgs := map[string]string{}
-
-		for _, arg := range c.StringSlice("config") {
-			parts := strings.SplitN(arg, "=", 2) // args are in key=value format
-
-			if len(parts) != 2 {
-				return fmt.Errorf("invalid config argument (expected format is --config key=value): %s", arg)
-			}
+		configArgs, err := parseConfigArgs(c.StringSlice("config"))
+		if err != nil {
+			return err
+		}
 
-			key := parts[0]
-			val := parts[1]
-			configArgs[key] = val
+		configValues := map[string]values.ConfigValue{}
+		for k, v := range vals.Config {
+			configValues[k] = v
+		}
+		for k, v := range configArgs {
+			configValues[k] = values.ConfigValue{Value: v}
 		}
 
 		// the client needs to be constructed as early as possible in the
@@ -120,12 +159,16 @@ var deployCommand = cli.Command{
 			return err
 		}
 
-		cfAccountID := c.String("common-fate-aws-account")
+		cfAccountID := flagOrValue(c, "common-fate-aws-account", vals.CommonFateAWSAccount)
 		if cfAccountID == "" {
 			clio.Warnf("using the current AWS account (%s) as the Common Fate account (use --common-fate-aws-account to override)", awsAccount)
 			cfAccountID = awsAccount
 		}
 
+		if provider == nil && !interactive {
+			return clierr.New("A provider must be set in the values file or with the --provider flag.")
+		}
+
 		if provider == nil {
 			provider, err = prompt.Provider(ctx, registry)
 			if err != nil {
@@ -133,51 +176,94 @@ var deployCommand = cli.Command{
 			}
 		}
 
-		bootstrapStackOutput, err := bs.GetOrDeployBootstrapBucket(ctx, deployer.WithConfirm(c.Bool("confirm-bootstrap")))
+		// check the provided config before deploying anything, rather than
+		// waiting for the Handler health check to report invalid config.
+		err = validateConfigValues(*provider, configValues)
 		if err != nil {
 			return err
 		}
 
-		selectedProviderKind, err := prompt.Kind(*provider)
+		// in a dry run, the bootstrap bucket isn't deployed if it is missing.
+		// bootstrapStackOutput is nil if the bootstrap bucket doesn't exist yet.
+		var bootstrapStackOutput *bootstrapper.BootstrapStackOutput
+		switch {
+		case dryRun:
+			bootstrapStackOutput, err = bs.Detect(ctx)
+			if errors.Is(err, bootstrapper.ErrNotDeployed) {
+				err = nil
+			}
+		case !interactive && !c.Bool("confirm-bootstrap"):
+			// deploying the bootstrap bucket prompts for confirmation, so fail instead.
+			bootstrapStackOutput, err = bs.Detect(ctx)
+			if errors.Is(err, bootstrapper.ErrNotDeployed) {
+				return clierr.New(fmt.Sprintf("The bootstrap bucket hasn't been deployed in %s.", awsContext.Config.Region),
+					clierr.Info("Run 'cf bootstrap' to deploy it, or add --confirm-bootstrap to deploy it as part of this command"),
+				)
+			}
+		default:
+			bootstrapStackOutput, err = bs.GetOrDeployBootstrapBucket(ctx, deployer.WithConfirm(c.Bool("confirm-bootstrap")))
+		}
 		if err != nil {
 			return err
 		}
 
-		clio.Info("Copying provider assets from the registry to the bootstrap bucket...")
-		err = bs.CopyProviderFiles(ctx, *provider)
+		selectedProviderKind, err := selectKind(*provider, flagOrValue(c, "target", vals.Kind), interactive)
 		if err != nil {
 			return err
 		}
-		clio.Success("Provider assets copied to the bootstrap bucket")
 
-		handlerID := c.String("handler-id")
+		handlerID := flagOrValue(c, "handler-id", vals.HandlerID)
 		if handlerID == "" {
 			handlerID = strings.Join([]string{"cf-handler", provider.Publisher, provider.Name}, "-")
 		}
 
-		var uniqueHandlerIDFound bool
+		// existingParams are the parameters of an existing stack for the Handler.
+		// If the stack exists, it is updated so that re-running this command
+		// converges on the same deployment.
+		var existingParams map[string]string
+
+		for {
+			existingParams, err = getStackParameters(ctx, awsContext.Config, handlerID)
+			if err != nil {
+				return err
+			}
+			if existingParams != nil {
+				break
+			}
 
-		for !uniqueHandlerIDFound {
 			// check if a lambda role already exists with the given ID
 			exists, err := checkIfLambdaRoleExists(ctx, awsContext.Config, handlerID)
 			if err != nil {
 				return err
 			}
 			if !exists {
-				uniqueHandlerIDFound = true
-			} else {
-				clio.Warnf("A Lambda function named '%s' already exists in the account. You will need to set a custom Handler ID.\nBy convention, we use 'cf-handler-[publisher]-[name]-[suffix]' as Handler IDs, for example: 'cf-handler-common-fate-aws-dev'.", handlerID)
-				err = survey.AskOne(&survey.Input{Message: "Unique Handler ID:"}, &handlerID)
-				if err != nil {
-					return err
-				}
+				break
+			}
+
+			if !interactive {
+				return clierr.New(fmt.Sprintf("A Lambda function named '%s' already exists in the account.", handlerID), clierr.Info("Set a unique handlerId in the values file"))
+			}
+
+			clio.Warnf("A Lambda function named '%s' already exists in the account. You will need to set a custom Handler ID.\nBy convention, we use 'cf-handler-[publisher]-[name]-[suffix]' as Handler IDs, for example: 'cf-handler-common-fate-aws-dev'.", handlerID)
+			err = survey.AskOne(&survey.Input{Message: "Unique Handler ID:"}, &handlerID)
+			if err != nil {
+				return err
 			}
 		}
 
-		lambdaAssetPath := path.Join("registry.commonfate.io", "v1alpha1", "providers", provider.Publisher, provider.Name, provider.Version)
+		if existingParams != nil {
+			deployed, ok := handlerstack.ProviderFromAssetPath(existingParams[handlerstack.ParamAssetPath])
+			if ok && (deployed.Publisher != provider.Publisher || deployed.Name != provider.Name) {
+				return clierr.New(fmt.Sprintf("The CloudFormation stack for Handler '%s' deploys a different provider (%s).", handlerID, deployed), clierr.Info("Use --handler-id to deploy to a different Handler"))
+			}
+			clio.Infof("Found an existing CloudFormation stack for Handler '%s', which will be updated", handlerID)
+		}
 
 		var oneLinerConfigArgs []string
 
+		// missingConfig are config keys which weren't provided in non-interactive mode.
+		var missingConfig []string
+
 		var parameters []types.Parameter
 
 		config := provider.Schema.Config
@@ -205,17 +291,41 @@ var deployCommand = cli.Command{
 				// the CloudFormation parameter value
 				var paramVal string
 
-				// check if it was provided as a CLI argument using --config key=value
-				if flagVal, ok := configArgs[k]; ok {
-					paramVal = flagVal
+				// check if it was provided as a CLI argument using --config key=value, or in the values file
+				if cv, ok := configValues[k]; ok {
+					paramVal, err = resolveConfigValue(ctx, resolveConfigValueOpts{
+						Secrets:   secretWriter,
+						IsSecret:  isSecret,
+						Key:       k,
+						HandlerID: handlerID,
+						Provider:  *provider,
+						Value:     cv,
+						DryRun:    dryRun,
+					})
+					if err != nil {
+						return errors.Wrapf(err, "config value %s", k)
+					}
+				} else if _, ok := existingParams[paramName]; ok {
+					// reuse the value from the existing stack rather than prompting again
+					parameters = append(parameters, types.Parameter{
+						ParameterKey:     aws.String(paramName),
+						UsePreviousValue: aws.Bool(true),
+					})
+					clio.Infof("Using the existing value of CloudFormation parameter %s", paramName)
+					continue
+				} else if !interactive {
+					missingConfig = append(missingConfig, k)
+					continue
 				} else {
 					// prompt the user interactively for the config values
 					paramVal, err = promptForConfig(ctx, promptForConfigOpts{
-						AWSCfg:    awsContext.Config,
+						Secrets:   secretWriter,
 						IsSecret:  isSecret,
 						Key:       k,
 						HandlerID: handlerID,
 						Provider:  *provider,
+						Schema:    v,
+						DryRun:    dryRun,
 					})
 					if err != nil {
 						return err
@@ -233,131 +343,415 @@ var deployCommand = cli.Command{
 			}
 		}
 
+		if len(missingConfig) > 0 {
+			return clierr.New(fmt.Sprintf("Missing config values: %s", strings.Join(missingConfig, ", ")), clierr.Info("Add the missing values to the 'config' section of the values file"))
+		}
+
 		parameters = append(parameters, types.Parameter{
-			ParameterKey:   aws.String("CommonFateAWSAccountID"),
+			ParameterKey:   aws.String(handlerstack.ParamCommonFateAWSAccountID),
 			ParameterValue: &cfAccountID,
 		})
 
 		parameters = append(parameters, types.Parameter{
-			ParameterKey:   aws.String("AssetPath"),
-			ParameterValue: aws.String(path.Join(lambdaAssetPath, "handler.zip")),
+			ParameterKey:   aws.String(handlerstack.ParamAssetPath),
+			ParameterValue: aws.String(handlerstack.AssetPath(provider.Base())),
 		})
 
+		assetsBucket := "<bootstrap bucket>"
+		if bootstrapStackOutput != nil {
+			assetsBucket = bootstrapStackOutput.AssetsBucket
+		}
+
 		parameters = append(parameters, types.Parameter{
-			ParameterKey:   aws.String("BootstrapBucketName"),
-			ParameterValue: aws.String(bootstrapStackOutput.AssetsBucket),
+			ParameterKey:   aws.String(handlerstack.ParamBootstrapBucketName),
+			ParameterValue: aws.String(assetsBucket),
 		})
 
 		parameters = append(parameters, types.Parameter{
-			ParameterKey:   aws.String("HandlerID"),
+			ParameterKey:   aws.String(handlerstack.ParamHandlerID),
 			ParameterValue: aws.String(handlerID),
 		})
 
-		targetgroupID := c.String("target-group-id")
+		targetgroupID := flagOrValue(c, "target-group-id", vals.TargetGroupID)
 		if targetgroupID == "" {
 			targetgroupID = strings.TrimPrefix(handlerID, "cf-handler-")
 		}
 
+		reg := registration{
+			TargetGroupID: targetgroupID,
+			Provider:      *provider,
+			Kind:          selectedProviderKind,
+			HandlerID:     handlerID,
+			AWSAccount:    awsAccount,
+			AWSRegion:     awsContext.Config.Region,
+		}
+
+		// check that the registration can be made before deploying anything,
+		// as an existing Target Group for a different provider or kind is an error.
+		_, err = planRegistration(ctx, cf, reg)
+		if err != nil {
+			return err
+		}
+
+		if dryRun {
+			return printDeployPlan(ctx, deployPlanOpts{
+				AWSCfg:          awsContext.Config,
+				CF:              cf,
+				Bootstrapper:    bs,
+				Bootstrap:       bootstrapStackOutput,
+				Registration:    reg,
+				Parameters:      parameters,
+				StackExists:     existingParams != nil,
+				CreateChangeSet: c.Bool("change-set"),
+			})
+		}
+
 		oneLinerCommand := fmt.Sprintf("cf provider deploy --common-fate-aws-account %s --handler-id %s --target-group-id %s --provider %s %s", cfAccountID, handlerID, targetgroupID, provider, strings.Join(oneLinerConfigArgs, " "))
+		if valuesFile != "" {
+			oneLinerCommand = fmt.Sprintf("cf provider deploy --values-file %s", valuesFile)
+		}
 
 		clio.NewLine()
 		clio.Infof("You can use the following one-liner command to redeploy this Provider in future:\n%s", oneLinerCommand)
 		clio.NewLine()
 
-		d := deployer.NewFromConfig(awsContext.Config)
-
-		clio.Infof("Deploying CloudFormation stack for Handler '%s'", handlerID)
+		dir, err := deployment.DefaultDir()
+		if err != nil {
+			return err
+		}
 
-		templateURL := bootstrapStackOutput.CloudFormationURL(provider.Base())
+		// the deployment record is saved as each step completes,
+		// so that the deployment can be resumed if it is interrupted.
+		rec := deployment.New(dir, handlerID)
+		rec.Provider = provider.String()
+		rec.Kind = selectedProviderKind
+		rec.TargetGroupID = targetgroupID
+		rec.AWSAccount = awsAccount
+		rec.AWSRegion = awsContext.Config.Region
+		rec.Parameters = recordParameters(parameters)
+		rec.NewStack = existingParams == nil
+		err = rec.Save()
+		if err != nil {
+			return err
+		}
 
-		out, err := d.Deploy(ctx, deployer.DeployOpts{
-			Template:  templateURL,
-			Params:    parameters,
-			StackName: handlerID,
-			Confirm:   true,
+		return runDeployment(ctx, runDeploymentOpts{
+			AWSCfg:            awsContext.Config,
+			CF:                cf,
+			Bootstrapper:      bs,
+			Registration:      reg,
+			Record:            rec,
+			RollbackOnFailure: c.Bool("rollback-on-failure"),
 		})
+	},
+}
+
+type runDeploymentOpts struct {
+	AWSCfg       aws.Config
+	CF           *client.Client
+	Bootstrapper *bootstrapper.Bootstrapper
+	Registration registration
+	Record       *deployment.Record
+	// RollbackOnFailure removes the resources created by the deployment if it fails.
+	RollbackOnFailure bool
+}
+
+// runDeployment copies the provider assets, deploys the Handler stack and registers
+// the Handler with Common Fate, skipping any steps which have already been completed.
+//
+// The deployment record is removed once the Handler is healthy. If the deployment
+// fails, the record is kept so that it can be resumed with 'cf provider deploy --resume',
+// unless the deployment is rolled back.
+func runDeployment(ctx context.Context, opts runDeploymentOpts) error {
+	err := runDeploymentSteps(ctx, opts)
+	if err != nil && opts.RollbackOnFailure {
+		clio.Errorf("Deploying Handler '%s' failed: %s", opts.Registration.HandlerID, err)
+
+		rollbackErr := rollbackDeployment(ctx, opts)
+		if rollbackErr != nil {
+			return clierr.New(fmt.Sprintf("Rolling back the deployment failed: %s", rollbackErr), clierr.Infof("The deployment progress has been saved to %s. To resume the deployment, run 'cf provider deploy --resume %s'", opts.Record.Path(), opts.Registration.HandlerID))
+		}
+		return clierr.New(err.Error(), clierr.Info("The deployment was rolled back"))
+	}
+	if err != nil {
+		return clierr.New(err.Error(), clierr.Infof("The deployment progress has been saved to %s. To resume the deployment, run 'cf provider deploy --resume %s'", opts.Record.Path(), opts.Registration.HandlerID))
+	}
+
+	return opts.Record.Remove()
+}
+
+func runDeploymentSteps(ctx context.Context, opts runDeploymentOpts) error {
+	r := opts.Registration
+	rec := opts.Record
+
+	if !rec.Completed(deployment.AssetsCopied) {
+		clio.Info("Copying provider assets from the registry to the bootstrap bucket...")
+		err := opts.Bootstrapper.CopyProviderFiles(ctx, r.Provider)
 		if err != nil {
 			return err
 		}
+		clio.Success("Provider assets copied to the bootstrap bucket")
 
-		// if the output of cloudformation deploy is not 'CREATE_COMPLETE'
-		// then should return error
-		if out.FinalStatus != "CREATE_COMPLETE" {
-			return fmt.Errorf("failed to deploy CloudFormation stack for Handler '%s", handlerID)
+		err = rec.Complete(deployment.AssetsCopied)
+		if err != nil {
+			return err
 		}
+	}
 
-		clio.Infof("Deployment completed for HandlerID %s", handlerID)
+	if rec.Completed(deployment.StackDeployed) {
+		clio.Infof("Skipping the CloudFormation stack for Handler '%s', which has already been deployed", r.HandlerID)
+	} else {
+		bootstrapStackOutput, err := opts.Bootstrapper.Detect(ctx)
+		if err != nil {
+			return err
+		}
 
-		clio.Infof("Creating a Target Group '%s' to route Access Requests to the Handler", targetgroupID)
+		clio.Infof("Deploying CloudFormation stack for Handler '%s'", r.HandlerID)
 
-		_, err = cf.AdminCreateTargetGroupWithResponse(ctx, cftypes.AdminCreateTargetGroupJSONRequestBody{
-			Id: targetgroupID,
-			From: cftypes.TargetGroupFrom{
-				Kind:      selectedProviderKind,
-				Name:      provider.Name,
-				Publisher: provider.Publisher,
-				Version:   provider.Version,
-			},
+		out, err := deployer.NewFromConfig(opts.AWSCfg).Deploy(ctx, deployer.DeployOpts{
+			Template:  bootstrapStackOutput.CloudFormationURL(r.Provider.Base()),
+			Params:    stackParameters(rec.Parameters),
+			StackName: r.HandlerID,
+			Confirm:   true,
 		})
 		if err != nil {
 			return err
 		}
-		clio.Successf("Target Group created: %s", targetgroupID)
 
-		// register the targetgroup with handler
+		// the stack is created on the first deployment, and updated (or skipped,
+		// if nothing has changed) if the command is run again.
+		switch out.FinalStatus {
+		case "CREATE_COMPLETE", "UPDATE_COMPLETE", "DEPLOY_SKIPPED":
+		default:
+			return fmt.Errorf("failed to deploy CloudFormation stack for Handler '%s': final status was %s", r.HandlerID, out.FinalStatus)
+		}
+
+		clio.Infof("Deployment completed for HandlerID %s", r.HandlerID)
 
-		reqBody := cftypes.AdminRegisterHandlerJSONRequestBody{
-			AwsAccount: awsAccount,
-			AwsRegion:  awsContext.Config.Region,
-			Runtime:    "aws-lambda",
-			Id:         handlerID,
+		stack, err := cfn.New(opts.AWSCfg).GetStack(ctx, r.HandlerID)
+		if err != nil {
+			return err
 		}
+		rec.StackID = aws.ToString(stack.StackId)
 
-		_, err = cf.AdminRegisterHandlerWithResponse(ctx, reqBody)
+		err = rec.Complete(deployment.StackDeployed)
 		if err != nil {
 			return err
 		}
+	}
 
-		clio.Successf("Successfully registered Handler '%s' with Common Fate", handlerID)
+	if !rec.Completed(deployment.Registered) {
+		changes, err := planRegistration(ctx, opts.CF, r)
+		if err != nil {
+			return err
+		}
 
-		_, err = cf.AdminCreateTargetGroupLinkWithResponse(ctx, targetgroupID, cftypes.AdminCreateTargetGroupLinkJSONRequestBody{
-			DeploymentId: handlerID,
-			Priority:     100,
-			Kind:         selectedProviderKind,
-		})
+		// record which resources are created before applying the changes,
+		// so that they can be removed if the deployment is rolled back.
+		for _, c := range changes {
+			if c.Action != spec.Create {
+				continue
+			}
+			switch {
+			case c.TargetGroup != nil:
+				rec.CreatesTargetGroup = true
+			case c.Handler != nil:
+				rec.CreatesHandler = true
+			case c.Link != nil:
+				rec.CreatesLink = true
+			}
+		}
+		err = rec.Save()
 		if err != nil {
 			return err
 		}
 
-		clio.Successf("Successfully linked Handler '%s' with Target Group '%s'", handlerID, targetgroupID)
+		err = spec.Apply(ctx, opts.CF, changes)
+		if err != nil {
+			return err
+		}
 
-		clio.Info("Waiting for Handler to become healthy...")
+		err = rec.Complete(deployment.Registered)
+		if err != nil {
+			return err
+		}
+	}
 
-		// retry every 5 seconds for a maximum of two minutes
-		err = retry.Do(ctx, retry.WithMaxDuration(time.Minute*2, retry.NewConstant(time.Second*5)), func(ctx context.Context) error {
-			ghr, err := cf.AdminGetHandlerWithResponse(ctx, handlerID)
-			if err != nil && ghr.StatusCode() < 500 {
-				return retry.RetryableError(err)
-			}
-			if err != nil {
-				return err
-			}
-			if ghr.JSON200.Healthy {
-				clio.Successf("Handler '%s' is healthy", handlerID)
-				return nil
-			}
+	clio.Successf("Handler '%s' is registered with Common Fate and linked with Target Group '%s'", r.HandlerID, r.TargetGroupID)
+
+	return waitForHealthyHandler(ctx, opts.CF, r.HandlerID)
+}
 
-			clio.Warnw("Handler is not healthy yet", "diagnostics", ghr.JSON200.Diagnostics)
+// recordParameters converts CloudFormation parameters to be saved in a deployment record.
+func recordParameters(params []types.Parameter) []deployment.Parameter {
+	var res []deployment.Parameter
+	for _, p := range params {
+		res = append(res, deployment.Parameter{
+			Key:              aws.ToString(p.ParameterKey),
+			Value:            aws.ToString(p.ParameterValue),
+			UsePreviousValue: aws.ToBool(p.UsePreviousValue),
+		})
+	}
+	return res
+}
 
-			// the below error will be shown to the user if the time limit is exceeded
-			return retry.RetryableError(errors.New("timed out waiting for Handler to become healthy"))
+// stackParameters converts the parameters saved in a deployment record to CloudFormation parameters.
+func stackParameters(params []deployment.Parameter) []types.Parameter {
+	var res []types.Parameter
+	for _, p := range params {
+		if p.UsePreviousValue {
+			res = append(res, types.Parameter{
+				ParameterKey:     aws.String(p.Key),
+				UsePreviousValue: aws.Bool(true),
+			})
+			continue
+		}
+		res = append(res, types.Parameter{
+			ParameterKey:   aws.String(p.Key),
+			ParameterValue: aws.String(p.Value),
 		})
+	}
+	return res
+}
+
+// registration is the Target Group, Handler and link
+// which are registered with Common Fate for a deployed provider.
+type registration struct {
+	TargetGroupID string
+	Provider      providerregistrysdk.ProviderDetail
+	Kind          string
+	HandlerID     string
+	AWSAccount    string
+	AWSRegion     string
+}
+
+// planRegistration returns the changes needed to register a deployed provider with Common Fate.
+// An existing Target Group, Handler registration and link are reused, so that
+// the deploy command can be run multiple times.
+//
+// An existing Target Group is never replaced, as that would relink every Handler
+// which it routes requests to. If it was created with a different provider or kind,
+// an error is returned instead.
+func planRegistration(ctx context.Context, cf *client.Client, r registration) ([]spec.Change, error) {
+	current, err := spec.Current(ctx, cf)
+	if err != nil {
+		return nil, err
+	}
+
+	want := spec.TargetGroup{
+		ID:       r.TargetGroupID,
+		Provider: r.Provider.String(),
+		Kind:     r.Kind,
+	}
+
+	desired := current.Copy()
+
+	existing, ok := findTargetGroup(*current, r.TargetGroupID)
+	switch {
+	case !ok:
+		desired.PutTargetGroup(want)
+	case existing.Provider != want.Provider || existing.Kind != want.Kind:
+		return nil, clierr.New(fmt.Sprintf("Target Group '%s' already exists for %s (kind '%s'), which is different to %s (kind '%s').", r.TargetGroupID, existing.Provider, existing.Kind, want.Provider, want.Kind),
+			clierr.Info("To change the provider version of a deployed Handler and its Target Groups, run 'cf provider upgrade'"),
+			clierr.Info("To register the Handler with a new Target Group, use --target-group-id"),
+		)
+	}
+
+	desired.PutHandler(spec.Handler{
+		ID:         r.HandlerID,
+		AWSAccount: r.AWSAccount,
+		AWSRegion:  r.AWSRegion,
+		Runtime:    spec.DefaultRuntime,
+	})
+	// keep the priority of an existing link, as it may have been changed since the provider was deployed.
+	if _, ok := current.FindLink(r.TargetGroupID, r.HandlerID, r.Kind); !ok {
+		desired.PutLink(spec.Link{
+			TargetGroup: r.TargetGroupID,
+			Handler:     r.HandlerID,
+			Kind:        r.Kind,
+			Priority:    spec.DefaultPriority,
+		})
+	}
+
+	return spec.Plan(desired, *current), nil
+}
+
+// findTargetGroup returns the Target Group with the given ID.
+func findTargetGroup(s spec.Spec, id string) (spec.TargetGroup, bool) {
+	for _, tg := range s.TargetGroups {
+		if tg.ID == id {
+			return tg, true
+		}
+	}
+	return spec.TargetGroup{}, false
+}
+
+// waitForHealthyHandler polls the Common Fate API until the Handler reports that it is healthy.
+func waitForHealthyHandler(ctx context.Context, cf *client.Client, handlerID string) error {
+	clio.Info("Waiting for Handler to become healthy...")
+
+	// retry every 5 seconds for a maximum of two minutes
+	return retry.Do(ctx, retry.WithMaxDuration(time.Minute*2, retry.NewConstant(time.Second*5)), func(ctx context.Context) error {
+		ghr, err := cf.AdminGetHandlerWithResponse(ctx, handlerID)
+		if err != nil && ghr.StatusCode() < 500 {
+			return retry.RetryableError(err)
+		}
 		if err != nil {
 			return err
 		}
+		if ghr.JSON200.Healthy {
+			clio.Successf("Handler '%s' is healthy", handlerID)
+			return nil
+		}
 
-		return nil
-	},
+		clio.Warnw("Handler is not healthy yet", "diagnostics", ghr.JSON200.Diagnostics)
+
+		// the below error will be shown to the user if the time limit is exceeded
+		return retry.RetryableError(errors.New("timed out waiting for Handler to become healthy"))
+	})
+}
+
+// parseConfigArgs parses config values provided in key=value format with the --config flag.
+func parseConfigArgs(args []string) (map[string]string, error) {
+	configArgs := map[string]string{}
+
+	for _, arg := range args {
+		parts := strings.SplitN(arg, "=", 2) // args are in key=value format
+
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("invalid config argument (expected format is --config key=value): %s", arg)
+		}
+
+		key := parts[0]
+		val := parts[1]
+		configArgs[key] = val
+	}
+
+	return configArgs, nil
+}
+
+// getStackParameters returns the parameters of an existing CloudFormation stack.
+// If the stack doesn't exist, nil is returned.
+func getStackParameters(ctx context.Context, cfg aws.Config, stackName string) (map[string]string, error) {
+	res, err := cloudformation.NewFromConfig(cfg).DescribeStacks(ctx, &cloudformation.DescribeStacksInput{
+		StackName: &stackName,
+	})
+	// the CloudFormation API returns a generic validation error if the stack doesn't exist
+	if err != nil && strings.Contains(err.Error(), "does not exist") {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(res.Stacks) == 0 || res.Stacks[0].StackStatus == types.StackStatusDeleteComplete {
+		return nil, nil
+	}
+
+	params := map[string]string{}
+	for _, p := range res.Stacks[0].Parameters {
+		params[aws.ToString(p.ParameterKey)] = aws.ToString(p.ParameterValue)
+	}
+	return params, nil
 }
 
 func checkIfLambdaRoleExists(ctx context.Context, cfg aws.Config, handlerID string) (exists bool, err error) {
@@ -378,56 +772,206 @@ func checkIfLambdaRoleExists(ctx context.Context, cfg aws.Config, handlerID stri
 }
 
 type promptForConfigOpts struct {
-	AWSCfg    aws.Config
+	Secrets   secrets.Writer
 	IsSecret  bool
 	Key       string
 	HandlerID string
 	Provider  providerregistrysdk.ProviderDetail
+	// Schema is the config schema for the key, which is used
+	// to validate the value and show its description.
+	Schema providerregistrysdk.Config
+	// DryRun skips prompting for secrets, as they aren't written to the secret backend.
+	DryRun bool
 }
 
 // promptForConfig prompts a user interactively for a config value.
-// If the value is a secret, it is written to SSM Parameter Store.
+// If the value is a secret, it is written to the secret backend.
 //
 // For non-secret config values, the value is returned.
-// For secrets, the secret reference is returned in the format "awsssm://<SECRET PATH>"
+// For secrets, the secret reference is returned (for example, "awsssm://<SECRET PATH>").
 func promptForConfig(ctx context.Context, opts promptForConfigOpts) (string, error) {
 	var paramVal string
 	if !opts.IsSecret {
 		// not a secret, so use the value directly
-		err := survey.AskOne(&survey.Input{Message: opts.Key + ":"}, &paramVal)
+		err := survey.AskOne(&survey.Input{Message: opts.Key + ":", Help: providerconfig.Help(opts.Schema)}, &paramVal, survey.WithValidator(providerconfig.Validator(opts.Schema)))
 		if err != nil {
 			return "", err
 		}
 		return paramVal, nil
 	}
 
-	// if we get here, its a secret, so write it to SSM Parameter store and return the key
-	client := ssm.NewFromConfig(opts.AWSCfg)
+	// if we get here, its a secret, so write it to the secret backend and return the reference
+	name := secretName(opts.HandlerID, opts.Key, opts.Provider)
+
+	if opts.DryRun {
+		clio.Infof("Dry run: skipping the prompt for %s, which would be stored in %s", opts.Key, opts.Secrets.Description(name))
+		return opts.Secrets.Reference(name), nil
+	}
 
 	var secret string
-	ssmKey := ssmkey.SSMKey(ssmkey.SSMKeyOpts{
-		HandlerID:    opts.HandlerID,
-		Key:          opts.Key,
-		Publisher:    opts.Provider.Publisher,
-		ProviderName: opts.Provider.Name,
-	})
-	helpMsg := fmt.Sprintf("This will be stored in AWS SSM Parameter Store with name '%s'", ssmKey)
-	err := survey.AskOne(&survey.Password{Message: opts.Key + ":", Help: helpMsg}, &secret)
+	helpMsg := providerconfig.Help(opts.Schema, fmt.Sprintf("This will be stored in %s", opts.Secrets.Description(name)))
+	err := survey.AskOne(&survey.Password{Message: opts.Key + ":", Help: helpMsg}, &secret, survey.WithValidator(providerconfig.Validator(opts.Schema)))
+	if err != nil {
+		return "", err
+	}
+
+	return writeSecret(ctx, opts.Secrets, name, secret)
+}
+
+// validateConfigValues checks the config values provided with the --config
+// flag or in a values file against the provider config schema. All invalid
+// values are reported together.
+//
+// Secrets which reference existing secrets are not validated, as the
+// CLI doesn't read the secret values.
+func validateConfigValues(provider providerregistrysdk.ProviderDetail, configValues map[string]values.ConfigValue) error {
+	schema := providerconfig.Schema(provider)
+
+	var keys []string
+	for k := range configValues {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var problems []string
+	for _, k := range keys {
+		s, ok := schema[k]
+		if !ok {
+			problems = append(problems, fmt.Sprintf("%s: unknown config key", k))
+			continue
+		}
+
+		cv := configValues[k]
+		if cv.FromSSM != "" {
+			if !providerconfig.IsSecret(s) {
+				problems = append(problems, fmt.Sprintf("%s: 'fromSSM' can only be used for secret config values", k))
+			}
+			continue
+		}
+
+		val, err := cv.Resolve()
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("%s: %s", k, err))
+			continue
+		}
+		if providerconfig.IsSecret(s) && secrets.IsReference(val) {
+			continue
+		}
+
+		err = providerconfig.Validate(s, val)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("%s: %s", k, err))
+		}
+	}
+
+	if len(problems) == 0 {
+		return nil
+	}
+
+	hint := clierr.Infof("%s doesn't have any config", provider)
+	if len(schema) > 0 {
+		hint = clierr.Infof("The config keys for %s are: %s", provider, strings.Join(providerconfig.Keys(schema), ", "))
+	}
+	return clierr.New(fmt.Sprintf("Invalid config for %s:\n  %s", provider, strings.Join(problems, "\n  ")), hint)
+}
+
+type resolveConfigValueOpts struct {
+	Secrets   secrets.Writer
+	IsSecret  bool
+	Key       string
+	HandlerID string
+	Provider  providerregistrysdk.ProviderDetail
+	Value     values.ConfigValue
+	// DryRun resolves secrets without writing them to the secret backend.
+	DryRun bool
+}
+
+// resolveConfigValue returns the CloudFormation parameter value for a config value
+// provided with the --config flag or in a values file.
+//
+// Secrets are written to the secret backend, unless they reference an existing secret.
+func resolveConfigValue(ctx context.Context, opts resolveConfigValueOpts) (string, error) {
+	if opts.Value.FromSSM != "" {
+		if !opts.IsSecret {
+			return "", errors.New("'fromSSM' can only be used for secret config values")
+		}
+		return secrets.SSMPrefix + opts.Value.FromSSM, nil
+	}
+
+	val, err := opts.Value.Resolve()
 	if err != nil {
 		return "", err
 	}
 
-	_, err = client.PutParameter(ctx, &ssm.PutParameterInput{
-		Name:      &ssmKey,
-		Value:     &secret,
-		Type:      ssmTypes.ParameterTypeSecureString,
-		Overwrite: aws.Bool(true),
+	// secrets which were provided with the --config flag in previous versions of the
+	// CLI (or from the printed one-liner) are already references to secrets.
+	if !opts.IsSecret || secrets.IsReference(val) {
+		return val, nil
+	}
+
+	name := secretName(opts.HandlerID, opts.Key, opts.Provider)
+	if opts.DryRun {
+		clio.Infof("Dry run: %s would be stored in %s", opts.Key, opts.Secrets.Description(name))
+		return opts.Secrets.Reference(name), nil
+	}
+
+	return writeSecret(ctx, opts.Secrets, name, val)
+}
+
+// secretName returns the name that a secret config value is stored with.
+func secretName(handlerID string, key string, provider providerregistrysdk.ProviderDetail) string {
+	return ssmkey.SSMKey(ssmkey.SSMKeyOpts{
+		HandlerID:    handlerID,
+		Key:          key,
+		Publisher:    provider.Publisher,
+		ProviderName: provider.Name,
 	})
+}
+
+// writeSecret writes a secret to the secret backend and returns the reference to it.
+func writeSecret(ctx context.Context, w secrets.Writer, name string, secret string) (string, error) {
+	err := w.Write(ctx, name, secret)
 	if err != nil {
 		return "", err
 	}
 
-	clio.Successf("Added to AWS SSM Parameter Store with name '%s'", ssmKey)
-	paramVal = "awsssm://" + ssmKey
-	return paramVal, nil
+	clio.Successf("Added to %s", w.Description(name))
+	return w.Reference(name), nil
+}
+
+// flagOrValue returns the value of a string flag if it is set,
+// and otherwise the value from the values file.
+func flagOrValue(c *cli.Context, flag string, value string) string {
+	if v := c.String(flag); v != "" {
+		return v
+	}
+	return value
+}
+
+// selectKind returns the target kind to deploy the provider with. If a kind
+// isn't provided and the provider has multiple kinds, the user is prompted
+// to select one, unless the deployment is non-interactive.
+func selectKind(provider providerregistrysdk.ProviderDetail, kind string, interactive bool) (string, error) {
+	var kinds []string
+	if provider.Schema.Targets != nil {
+		for k := range *provider.Schema.Targets {
+			kinds = append(kinds, k)
+		}
+	}
+	sort.Strings(kinds)
+
+	if kind != "" {
+		for _, k := range kinds {
+			if k == kind {
+				return kind, nil
+			}
+		}
+		return "", clierr.New(fmt.Sprintf("%s does not grant access to targets of kind '%s'.", provider, kind), clierr.Infof("Available kinds: %s", strings.Join(kinds, ", ")))
+	}
+
+	if !interactive && len(kinds) > 1 {
+		return "", clierr.New(fmt.Sprintf("%s grants access to multiple kinds of targets, so a kind must be provided.", provider), clierr.Infof("Available kinds: %s", strings.Join(kinds, ", ")))
+	}
+
+	return prompt.Kind(provider)
 }
@@ -1,15 +1,26 @@
 package provider
 
 import (
+	"context"
 	"fmt"
+	"os"
 	"strings"
 
+	"github.com/AlecAivazis/survey/v2"
+	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/cloudformation"
+	cftypes "github.com/aws/aws-sdk-go-v2/service/cloudformation/types"
 	"github.com/common-fate/clio"
+	"github.com/common-fate/clio/clierr"
 	"github.com/common-fate/cloudform/deployer"
 	"github.com/common-fate/glide-cli/cmd/middleware"
 	"github.com/common-fate/glide-cli/pkg/client"
 	cfconfig "github.com/common-fate/glide-cli/pkg/config"
+	"github.com/common-fate/glide-cli/pkg/handlerstack"
+	"github.com/common-fate/glide-cli/pkg/secrets"
+	"github.com/common-fate/glide-cli/pkg/spec"
+	"github.com/common-fate/glide-cli/pkg/ssmkey"
+	"github.com/common-fate/glide-cli/pkg/table"
 	"github.com/common-fate/provider-registry-sdk-go/pkg/handlerclient"
 	"github.com/common-fate/provider-registry-sdk-go/pkg/providerregistrysdk"
 	"github.com/urfave/cli/v2"
@@ -23,7 +34,10 @@ var destroyCommand = cli.Command{
 		&cli.StringFlag{Name: "handler-id", Usage: "The Handler ID to remove", Required: true},
 		&cli.StringFlag{Name: "target-group-id", Usage: "Override the ID of the Target Group which will be deleted"},
 		&cli.BoolFlag{Name: "delete-cloudformation-stack", Usage: "Delete the CloudFormation stack for the Handler", Value: true},
-		&cli.BoolFlag{Name: "confirm", Aliases: []string{"y"}, Usage: "Confirm the deletion of resources"},
+		&cli.BoolFlag{Name: "confirm", Aliases: []string{"y"}, Usage: "Delete the resources without asking for confirmation"},
+		&cli.BoolFlag{Name: "keep-secrets", Usage: "Don't delete the SSM parameters containing the Handler's secret config values"},
+		&cli.BoolFlag{Name: "dry-run", Usage: "List the resources which would be removed, without deleting anything"},
+		&cli.BoolFlag{Name: "force", Usage: "Delete the Target Group even if it is linked to other Handlers"},
 	},
 	Action: func(c *cli.Context) error {
 		ctx := c.Context
@@ -70,50 +84,91 @@ var destroyCommand = cli.Command{
 			clio.Errorf("Error when describing Handler Lambda function (continuing with deletion anyway): %s", err.Error())
 		}
 
-		d := deployer.NewFromConfig(awsContext.Config)
+		targetGroupID := c.String("target-group-id")
+		if targetGroupID == "" {
+			targetGroupID = strings.TrimPrefix(handlerID, "cf-handler-")
+		}
 
-		if c.Bool("delete-cloudformation-stack") {
+		plan, err := planDestroy(ctx, planDestroyOpts{
+			AWSCfg:        awsContext.Config,
+			CF:            cf,
+			HandlerID:     handlerID,
+			TargetGroupID: targetGroupID,
+			Desc:          desc,
+			DeleteStack:   c.Bool("delete-cloudformation-stack"),
+			KeepSecrets:   c.Bool("keep-secrets"),
+		})
+		if err != nil {
+			return err
+		}
 
-			//check to see if cloudformation stack exists
-			client := cloudformation.NewFromConfig(awsContext.Config)
-			res, err := client.DescribeStacks(ctx, &cloudformation.DescribeStacksInput{
-				StackName: &handlerID,
-			})
+		if len(plan.OtherHandlers) > 0 && !c.Bool("force") {
+			return clierr.New(fmt.Sprintf("Target Group '%s' is also linked to other Handlers: %s", targetGroupID, strings.Join(plan.OtherHandlers, ", ")),
+				clierr.Info("Deleting the Target Group would break its routes to these Handlers"),
+				clierr.Info("Use --target-group-id to choose a different Target Group, or --force to delete it anyway"),
+			)
+		}
+
+		if plan.empty() {
+			clio.Infof("Handler '%s' has already been removed: there is nothing to destroy", handlerID)
+			return nil
+		}
+
+		err = printDestroyPlan(plan)
+		if err != nil {
+			return err
+		}
+
+		if c.Bool("dry-run") {
+			clio.NewLine()
+			clio.Infof("Dry run: nothing was deleted")
+			return nil
+		}
+
+		if !c.Bool("confirm") {
+			var confirm bool
+			err = survey.AskOne(&survey.Confirm{Message: fmt.Sprintf("Destroy Handler '%s'?", handlerID)}, &confirm)
 			if err != nil {
 				return err
 			}
-			if len(res.Stacks) == 0 {
-				return fmt.Errorf("could not find stack %s", handlerID)
+			if !confirm {
+				return clierr.New("Cancelled: nothing was deleted")
 			}
+		}
 
+		if plan.StackExists {
 			clio.Infof("Deleting CloudFormation stack '%s'", handlerID)
 
-			_, err = d.Delete(ctx, deployer.DeleteOpts{
+			out, err := deployer.NewFromConfig(awsContext.Config).Delete(ctx, deployer.DeleteOpts{
 				StackName: handlerID,
 			})
 			if err != nil {
 				return err
 			}
+			if out.FinalStatus != "DELETE_COMPLETE" {
+				return fmt.Errorf("failed to delete CloudFormation stack '%s': final status was %s", handlerID, out.FinalStatus)
+			}
 		}
 
-		targetgroupID := c.String("target-group-id")
-		if targetgroupID == "" {
-			targetgroupID = strings.TrimPrefix(handlerID, "cf-handler-")
-		}
-
-		clio.Infof("Deleting Target Group '%s'", targetgroupID)
-		_, err = cf.AdminDeleteTargetGroupWithResponse(ctx, targetgroupID)
+		err = spec.Apply(ctx, cf, plan.Changes)
 		if err != nil {
 			return err
 		}
 
-		clio.Infof("Deleting Handler '%s'", targetgroupID)
-		_, err = cf.AdminDeleteHandlerWithResponse(ctx, handlerID)
-		if err != nil {
-			return err
+		// secrets are deleted last, so that they are kept if removing the
+		// Handler fails and the destroy command needs to be run again.
+		if len(plan.SecretParams) > 0 {
+			clio.Infof("Deleting %d SSM parameters", len(plan.SecretParams))
+			err = secrets.DeleteSSM(ctx, awsContext.Config, plan.SecretParams)
+			if err != nil {
+				return err
+			}
 		}
 
 		clio.Successf("Handler '%s' has been removed", handlerID)
+		if len(plan.SecretParams) > 0 {
+			clio.Infof("Deleted SSM parameters:\n%s", strings.Join(plan.SecretParams, "\n"))
+		}
 		if desc != nil {
 			clio.Infof("You can deploy this handler again by running:\ncf provider deploy -p %s --handler-id %s", desc.Provider, handlerID)
 		}
@@ -121,3 +176,199 @@ var destroyCommand = cli.Command{
 		return nil
 	},
 }
+
+type planDestroyOpts struct {
+	AWSCfg        aws.Config
+	CF            *client.Client
+	HandlerID     string
+	TargetGroupID string
+	// Desc is nil if the Handler couldn't be described.
+	Desc        *providerregistrysdk.DescribeResponse
+	DeleteStack bool
+	KeepSecrets bool
+}
+
+// destroyPlan is everything that 'cf provider destroy' removes.
+type destroyPlan struct {
+	HandlerID string
+	Region    string
+	// StackExists is false if the stack has already been deleted,
+	// or if the stack isn't being deleted.
+	StackExists    bool
+	StackResources []cftypes.StackResourceSummary
+	// Changes removes the Handler, the Target Group and their links from Common Fate.
+	Changes []spec.Change
+	// OtherHandlers are the Handlers other than the one being destroyed
+	// which the Target Group routes requests to.
+	OtherHandlers []string
+	// Orphaned are the Target Groups which will no longer route
+	// requests to any Handler once the links are removed.
+	Orphaned     []string
+	SecretParams []string
+}
+
+func (p destroyPlan) empty() bool {
+	return !p.StackExists && len(p.Changes) == 0 && len(p.SecretParams) == 0
+}
+
+// planDestroy finds the resources that will be removed, without changing anything.
+func planDestroy(ctx context.Context, opts planDestroyOpts) (*destroyPlan, error) {
+	plan := destroyPlan{HandlerID: opts.HandlerID, Region: opts.AWSCfg.Region}
+
+	if opts.DeleteStack {
+		params, err := getStackParameters(ctx, opts.AWSCfg, opts.HandlerID)
+		if err != nil {
+			return nil, err
+		}
+		plan.StackExists = params != nil
+	}
+	if plan.StackExists {
+		p := cloudformation.NewListStackResourcesPaginator(cloudformation.NewFromConfig(opts.AWSCfg), &cloudformation.ListStackResourcesInput{
+			StackName: &opts.HandlerID,
+		})
+		for p.HasMorePages() {
+			page, err := p.NextPage(ctx)
+			if err != nil {
+				return nil, err
+			}
+			plan.StackResources = append(plan.StackResources, page.StackResourceSummaries...)
+		}
+	}
+
+	current, err := spec.Current(ctx, opts.CF)
+	if err != nil {
+		return nil, err
+	}
+
+	desired := current.Copy()
+	desired.RemoveHandler(opts.HandlerID)
+	desired.RemoveTargetGroup(opts.TargetGroupID)
+	plan.Changes = spec.Plan(desired, *current)
+
+	// a Handler may be linked to the Target Group more than once, for different kinds.
+	others := map[string]bool{}
+	for _, l := range current.Links {
+		if l.TargetGroup == opts.TargetGroupID && l.Handler != opts.HandlerID && !others[l.Handler] {
+			others[l.Handler] = true
+			plan.OtherHandlers = append(plan.OtherHandlers, l.Handler)
+		}
+	}
+
+	for _, tg := range desired.TargetGroups {
+		if hasLinks(*current, tg.ID) && !hasLinks(desired, tg.ID) {
+			plan.Orphaned = append(plan.Orphaned, tg.ID)
+		}
+	}
+
+	// find the secrets before deleting the stack, as the stack parameters
+	// are used to find the provider if the Handler can't be described.
+	if !opts.KeepSecrets {
+		plan.SecretParams, err = listHandlerSecrets(ctx, opts.AWSCfg, opts.HandlerID, opts.Desc)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &plan, nil
+}
+
+func hasLinks(s spec.Spec, targetGroupID string) bool {
+	for _, l := range s.Links {
+		if l.TargetGroup == targetGroupID {
+			return true
+		}
+	}
+	return false
+}
+
+// printDestroyPlan lists everything that will be removed.
+func printDestroyPlan(plan *destroyPlan) error {
+	clio.NewLine()
+	clio.Log("CloudFormation")
+	if !plan.StackExists {
+		clio.Logf("The stack '%s' will not be deleted", plan.HandlerID)
+	} else {
+		clio.Logf("The stack '%s' in %s will be deleted, along with %d resources", plan.HandlerID, plan.Region, len(plan.StackResources))
+		w := table.New(os.Stdout)
+		w.Columns("LOGICAL ID", "TYPE", "PHYSICAL ID")
+		for _, r := range plan.StackResources {
+			w.Row(aws.ToString(r.LogicalResourceId), aws.ToString(r.ResourceType), aws.ToString(r.PhysicalResourceId))
+		}
+		err := w.Flush()
+		if err != nil {
+			return err
+		}
+	}
+
+	clio.NewLine()
+	clio.Log("Common Fate")
+	if len(plan.Changes) == 0 {
+		clio.Log("The Handler and Target Group are not registered with Common Fate")
+	} else {
+		err := spec.PrintPlan(os.Stdout, plan.Changes)
+		if err != nil {
+			return err
+		}
+	}
+	for _, c := range plan.Changes {
+		if c.Link != nil {
+			clio.Warnf("Requests for kind '%s' in Target Group '%s' will no longer be routed to Handler '%s'", c.Link.Kind, c.Link.TargetGroup, c.Link.Handler)
+		}
+	}
+	for _, tg := range plan.Orphaned {
+		clio.Warnf("Target Group '%s' will not be linked to any Handlers", tg)
+	}
+
+	clio.NewLine()
+	clio.Log("SSM Parameter Store")
+	if len(plan.SecretParams) == 0 {
+		clio.Log("No SSM parameters will be deleted")
+	} else {
+		clio.Logf("These SSM parameters will be deleted:\n%s", strings.Join(plan.SecretParams, "\n"))
+	}
+
+	clio.NewLine()
+	return nil
+}
+
+// listHandlerSecrets returns the names of the SSM parameters stored under the Handler's secret path.
+func listHandlerSecrets(ctx context.Context, awsCfg aws.Config, handlerID string, desc *providerregistrysdk.DescribeResponse) ([]string, error) {
+	provider, ok := handlerProvider(ctx, awsCfg, handlerID, desc)
+	if !ok {
+		clio.Warnf("Could not determine the provider for Handler '%s', so its SSM parameters won't be deleted", handlerID)
+		return nil, nil
+	}
+
+	stored, err := secrets.ListSSM(ctx, awsCfg, ssmkey.Prefix(provider.Publisher, provider.Name, handlerID))
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	for _, info := range stored {
+		names = append(names, info.Name)
+	}
+	return names, nil
+}
+
+// handlerProvider returns the provider that a Handler runs. If the Handler
+// can't be described, the provider is read from the asset path of its stack.
+func handlerProvider(ctx context.Context, awsCfg aws.Config, handlerID string, desc *providerregistrysdk.DescribeResponse) (providerregistrysdk.Provider, bool) {
+	if desc != nil {
+		return desc.Provider, true
+	}
+
+	res, err := cloudformation.NewFromConfig(awsCfg).DescribeStacks(ctx, &cloudformation.DescribeStacksInput{
+		StackName: &handlerID,
+	})
+	if err != nil || len(res.Stacks) == 0 {
+		return providerregistrysdk.Provider{}, false
+	}
+
+	for _, p := range res.Stacks[0].Parameters {
+		if aws.ToString(p.ParameterKey) == handlerstack.ParamAssetPath {
+			return handlerstack.ProviderFromAssetPath(aws.ToString(p.ParameterValue))
+		}
+	}
+	return providerregistrysdk.Provider{}, false
+}
@@ -0,0 +1,175 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudformation/types"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/common-fate/clio"
+	"github.com/common-fate/clio/clierr"
+	"github.com/common-fate/cloudform/cfn"
+	"github.com/common-fate/cloudform/ui"
+	"github.com/common-fate/glide-cli/pkg/client"
+	"github.com/common-fate/glide-cli/pkg/spec"
+	"github.com/common-fate/glide-cli/pkg/table"
+	"github.com/common-fate/provider-registry-sdk-go/pkg/bootstrapper"
+)
+
+// noChangesMsg is returned by the CloudFormation API when
+// a change set doesn't contain any changes to the stack.
+const noChangesMsg = "The submitted information didn't contain changes. Submit different information to create a change set."
+
+type deployPlanOpts struct {
+	AWSCfg       aws.Config
+	CF           *client.Client
+	Bootstrapper *bootstrapper.Bootstrapper
+	// Bootstrap is nil if the bootstrap bucket hasn't been deployed.
+	Bootstrap    *bootstrapper.BootstrapStackOutput
+	Registration registration
+	Parameters   []types.Parameter
+	StackExists  bool
+	// CreateChangeSet creates a CloudFormation change set
+	// for the Handler stack, without executing it.
+	CreateChangeSet bool
+}
+
+// printDeployPlan prints the changes that 'cf provider deploy' would make,
+// without changing anything in AWS or Common Fate (unless a change set is requested).
+func printDeployPlan(ctx context.Context, opts deployPlanOpts) error {
+	r := opts.Registration
+	provider := r.Provider.Base()
+
+	clio.NewLine()
+	clio.Infof("Dry run: %s will not be deployed", provider)
+
+	clio.NewLine()
+	clio.Log("Bootstrap")
+	assetsExist := false
+	if opts.Bootstrap == nil {
+		clio.Logf("The bootstrap bucket will be deployed with the CloudFormation stack '%s' in %s", bootstrapper.BootstrapStackName, opts.AWSCfg.Region)
+	} else {
+		var err error
+		assetsExist, err = providerAssetsExist(ctx, opts.AWSCfg, opts.Bootstrap.AssetsBucket, bootstrapper.AssetPath(provider))
+		if err != nil {
+			return err
+		}
+		clio.Logf("Using the existing bootstrap bucket %s", opts.Bootstrap.AssetsBucket)
+	}
+	if assetsExist {
+		clio.Log("The provider assets already exist in the bootstrap bucket")
+	} else {
+		clio.Log("The provider assets will be copied from the Provider Registry to the bootstrap bucket")
+	}
+
+	templateURL := "<bootstrap bucket template URL>"
+	if opts.Bootstrap != nil {
+		templateURL = opts.Bootstrap.CloudFormationURL(provider)
+	}
+
+	stackAction := "created"
+	if opts.StackExists {
+		stackAction = "updated"
+	}
+
+	clio.NewLine()
+	clio.Log("CloudFormation")
+	clio.Logf("The stack '%s' will be %s with the template %s", r.HandlerID, stackAction, templateURL)
+	w := table.New(os.Stdout)
+	w.Columns("PARAMETER", "VALUE")
+	for _, p := range opts.Parameters {
+		val := aws.ToString(p.ParameterValue)
+		if aws.ToBool(p.UsePreviousValue) {
+			val = "(existing value)"
+		}
+		w.Row(aws.ToString(p.ParameterKey), val)
+	}
+	err := w.Flush()
+	if err != nil {
+		return err
+	}
+
+	changes, err := planRegistration(ctx, opts.CF, r)
+	if err != nil {
+		return err
+	}
+
+	clio.NewLine()
+	clio.Log("Common Fate")
+	if len(changes) == 0 {
+		clio.Logf("Handler '%s' is already registered and linked with Target Group '%s'", r.HandlerID, r.TargetGroupID)
+	} else {
+		err = spec.PrintPlan(os.Stdout, changes)
+		if err != nil {
+			return err
+		}
+	}
+
+	if !opts.CreateChangeSet {
+		clio.NewLine()
+		clio.Info("Dry run: no changes were made (use --change-set to preview the CloudFormation changes)")
+		return nil
+	}
+
+	return createChangeSet(ctx, opts, templateURL)
+}
+
+// createChangeSet creates a CloudFormation change set for the Handler stack and prints it.
+// The change set is left for the user to review and is not executed.
+func createChangeSet(ctx context.Context, opts deployPlanOpts, templateURL string) error {
+	r := opts.Registration
+
+	if opts.Bootstrap == nil {
+		return clierr.New("A change set can't be created because the bootstrap bucket hasn't been deployed.", clierr.Info("Run 'cf bootstrap' to deploy the bootstrap bucket, then try again"))
+	}
+
+	// CloudFormation reads the template from the bootstrap bucket.
+	clio.Info("Copying provider assets from the registry to the bootstrap bucket...")
+	err := opts.Bootstrapper.CopyProviderFiles(ctx, r.Provider)
+	if err != nil {
+		return err
+	}
+
+	changeSetName, err := cfn.New(opts.AWSCfg).CreateChangeSet(ctx, templateURL, opts.Parameters, nil, r.HandlerID, "")
+	if err != nil && err.Error() == noChangesMsg {
+		clio.Infof("There are no changes to the CloudFormation stack '%s'", r.HandlerID)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	status, err := ui.New(opts.AWSCfg).FormatChangeSet(ctx, r.HandlerID, changeSetName)
+	if err != nil {
+		return err
+	}
+
+	clio.NewLine()
+	clio.Info("The following CloudFormation changes will be made:")
+	fmt.Println(status)
+
+	clio.Successf("Created change set '%s' for the stack '%s'. The change set has not been executed.", changeSetName, r.HandlerID)
+	if !opts.StackExists {
+		clio.Warnf("CloudFormation has created the stack '%s' in the REVIEW_IN_PROGRESS state. Execute the change set to deploy the stack, or delete the stack before running 'cf provider deploy'.", r.HandlerID)
+	}
+	return nil
+}
+
+// providerAssetsExist returns true if the handler and CloudFormation template
+// for a provider have been copied to the bootstrap bucket.
+func providerAssetsExist(ctx context.Context, cfg aws.Config, bucket string, assetPath string) (bool, error) {
+	client := s3.NewFromConfig(cfg)
+	for _, file := range []string{"handler.zip", "cloudformation.json"} {
+		exists, err := bootstrapper.AssetsExist(ctx, client, bucket, path.Join(assetPath, file))
+		if err != nil {
+			return false, err
+		}
+		if !exists {
+			return false, nil
+		}
+	}
+	return true, nil
+}
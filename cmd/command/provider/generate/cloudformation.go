@@ -19,6 +19,8 @@ import (
 
 	"github.com/common-fate/clio"
 	"github.com/common-fate/glide-cli/pkg/fmtconvert"
+	"github.com/common-fate/glide-cli/pkg/providerconfig"
+	"github.com/common-fate/glide-cli/pkg/secrets"
 	"github.com/common-fate/glide-cli/pkg/ssmkey"
 	"github.com/common-fate/provider-registry-sdk-go/pkg/providerregistrysdk"
 	registryclient "github.com/common-fate/provider-registry-sdk-go/pkg/registryclient"
@@ -61,13 +63,13 @@ var cloudFormationCreate = cli.Command{
 	Name:    "cloudformation-create",
 	Aliases: []string{"cfn-create"},
 	Usage:   "Generate an 'aws cloudformation create-stack' command",
-	Flags: []cli.Flag{
+	Flags: append([]cli.Flag{
 		&cli.StringFlag{Name: "provider-id", Required: true, Usage: "publisher/name@version"},
 		&cli.StringFlag{Name: "handler-id", Required: true, Usage: "The ID of the Handler (for example, 'cf-handler-aws')"},
 		&cli.StringFlag{Name: "bootstrap-bucket", Required: true},
 		&cli.StringFlag{Name: "common-fate-aws-account", Usage: "The AWS account where Common Fate is deployed"},
 		&cli.StringFlag{Name: "region", Usage: "The region to deploy the handler"},
-	},
+	}, secrets.Flags()...),
 	Action: func(c *cli.Context) error {
 		ctx := c.Context
 		bootstrapBucket := c.String("bootstrap-bucket")
@@ -119,13 +121,14 @@ var cloudFormationCreate = cli.Command{
 			return err
 		}
 
+		secretWriter := secrets.New(awsCfg, secrets.OptsFromFlags(c))
+
 		config := res.JSON200.Schema.Config
 		if config != nil {
 			clio.Info("Enter the values for your configurations:")
-			for k, v := range *config {
+			for _, k := range providerconfig.Keys(*config) {
+				v := (*config)[k]
 				if v.Secret != nil && *v.Secret {
-					client := ssm.NewFromConfig(awsCfg)
-
 					var secret string
 					name := ssmkey.SSMKey(ssmkey.SSMKeyOpts{
 						HandlerID:    handlerID,
@@ -134,36 +137,31 @@ var cloudFormationCreate = cli.Command{
 						ProviderName: provider.Name,
 					})
 
-					helpMsg := fmt.Sprintf("This will be stored in AWS SSM Parameter Store with name '%s'", name)
-					err = survey.AskOne(&survey.Password{Message: k + ":", Help: helpMsg}, &secret)
+					helpMsg := providerconfig.Help(v, fmt.Sprintf("This will be stored in %s", secretWriter.Description(name)))
+					err = survey.AskOne(&survey.Password{Message: k + ":", Help: helpMsg}, &secret, survey.WithValidator(providerconfig.Validator(v)))
 					if err != nil {
 						return err
 					}
 
-					_, err = client.PutParameter(ctx, &ssm.PutParameterInput{
-						Name:      aws.String(name),
-						Value:     aws.String(secret),
-						Type:      types.ParameterTypeSecureString,
-						Overwrite: aws.Bool(true),
-					})
+					err = secretWriter.Write(ctx, name, secret)
 					if err != nil {
 						return err
 					}
 
-					clio.Successf("Added to AWS SSM Parameter Store with name '%s'", name)
+					clio.Successf("Added to %s", secretWriter.Description(name))
 
 					// secret config should have "Secret" prefix to the config key name.
-					values[fmtconvert.PascalCase(k)+"Secret"] = name
+					values[fmtconvert.PascalCase(k)+"Secret"] = secretWriter.Reference(name)
 
 					continue
 				}
 
-				var v string
-				err = survey.AskOne(&survey.Input{Message: k + ":"}, &v)
+				var val string
+				err = survey.AskOne(&survey.Input{Message: k + ":", Help: providerconfig.Help(v)}, &val, survey.WithValidator(providerconfig.Validator(v)))
 				if err != nil {
 					return err
 				}
-				values[fmtconvert.PascalCase(k)] = v
+				values[fmtconvert.PascalCase(k)] = val
 
 			}
 		}
@@ -25,6 +25,7 @@ var Command = cli.Command{
 		&ListCommand,
 		&generate.Command,
 		mw.WithBeforeFuncs(&deployCommand, mw.RequireAWSCredentials()),
+		mw.WithBeforeFuncs(&upgradeCommand, mw.RequireAWSCredentials()),
 		mw.WithBeforeFuncs(&destroyCommand, mw.RequireAWSCredentials()),
 	},
 }
@@ -0,0 +1,88 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/common-fate/clio"
+	"github.com/common-fate/clio/clierr"
+	"github.com/common-fate/glide-cli/cmd/middleware"
+	"github.com/common-fate/glide-cli/pkg/client"
+	cfconfig "github.com/common-fate/glide-cli/pkg/config"
+	"github.com/common-fate/glide-cli/pkg/deployment"
+	"github.com/common-fate/provider-registry-sdk-go/pkg/bootstrapper"
+	"github.com/common-fate/provider-registry-sdk-go/pkg/providerregistrysdk"
+	registryclient "github.com/common-fate/provider-registry-sdk-go/pkg/registryclient"
+	"github.com/pkg/errors"
+)
+
+// resumeDeployment continues an interrupted 'cf provider deploy' from the
+// last completed step, using the deployment record for the Handler.
+func resumeDeployment(ctx context.Context, awsContext middleware.AWSContext, registry *registryclient.Client, handlerID string, rollbackOnFailure bool) error {
+	dir, err := deployment.DefaultDir()
+	if err != nil {
+		return err
+	}
+
+	rec, err := deployment.Load(dir, handlerID)
+	if errors.Is(err, deployment.ErrNotFound) {
+		return clierr.New(fmt.Sprintf("There is no deployment of Handler '%s' to resume.", handlerID), clierr.Infof("Deployment records are stored in %s and are removed once a deployment completes", dir))
+	}
+	if err != nil {
+		return err
+	}
+
+	awsAccount, err := awsContext.Account(ctx)
+	if err != nil {
+		return err
+	}
+
+	if rec.AWSAccount != awsAccount || rec.AWSRegion != awsContext.Config.Region {
+		return clierr.New(fmt.Sprintf("The deployment of Handler '%s' was started in account %s (%s), but your AWS credentials are for account %s (%s).", handlerID, rec.AWSAccount, rec.AWSRegion, awsAccount, awsContext.Config.Region), clierr.Info("Use AWS credentials for the account and region the deployment was started in"))
+	}
+
+	p, err := providerregistrysdk.ParseProvider(rec.Provider)
+	if err != nil {
+		return err
+	}
+	res, err := registry.GetProviderWithResponse(ctx, p.Publisher, p.Name, p.Version)
+	if err != nil {
+		return err
+	}
+
+	cfg, err := cfconfig.Load()
+	if err != nil {
+		return err
+	}
+
+	cf, err := client.FromConfig(ctx, cfg)
+	if err != nil {
+		return err
+	}
+
+	var completed []string
+	for _, s := range rec.CompletedSteps {
+		completed = append(completed, string(s))
+	}
+	if len(completed) == 0 {
+		completed = append(completed, "none")
+	}
+	clio.Infof("Resuming the deployment of %s to Handler '%s' (completed steps: %s)", rec.Provider, handlerID, strings.Join(completed, ", "))
+
+	return runDeployment(ctx, runDeploymentOpts{
+		AWSCfg:       awsContext.Config,
+		CF:           cf,
+		Bootstrapper: bootstrapper.NewFromConfig(awsContext.Config),
+		Registration: registration{
+			TargetGroupID: rec.TargetGroupID,
+			Provider:      *res.JSON200,
+			Kind:          rec.Kind,
+			HandlerID:     rec.HandlerID,
+			AWSAccount:    rec.AWSAccount,
+			AWSRegion:     rec.AWSRegion,
+		},
+		Record:            rec,
+		RollbackOnFailure: rollbackOnFailure,
+	})
+}
@@ -0,0 +1,73 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/common-fate/clio"
+	"github.com/common-fate/cloudform/deployer"
+	"github.com/common-fate/glide-cli/pkg/spec"
+)
+
+// rollbackDeployment undoes the steps of a failed deployment in reverse order:
+// the link is removed, the Handler is deregistered, the Target Group is deleted
+// and finally the CloudFormation stack is deleted.
+//
+// Only resources created by the deployment are removed. Existing resources which
+// the deployment reused or updated are left in place.
+func rollbackDeployment(ctx context.Context, opts runDeploymentOpts) error {
+	r := opts.Registration
+	rec := opts.Record
+
+	clio.Warnf("Rolling back the deployment of Handler '%s'", r.HandlerID)
+
+	current, err := spec.Current(ctx, opts.CF)
+	if err != nil {
+		return err
+	}
+
+	desired := current.Copy()
+	if rec.CreatesLink {
+		desired.RemoveLink(r.TargetGroupID, r.HandlerID, r.Kind)
+	}
+	if rec.CreatesHandler {
+		desired.RemoveHandler(r.HandlerID)
+	}
+	if rec.CreatesTargetGroup {
+		desired.RemoveTargetGroup(r.TargetGroupID)
+	}
+
+	// resources which weren't created before the deployment failed
+	// aren't in the current state, so there is nothing to remove for them.
+	err = spec.Apply(ctx, opts.CF, spec.Plan(desired, *current))
+	if err != nil {
+		return err
+	}
+
+	if !rec.NewStack {
+		clio.Infof("The CloudFormation stack '%s' existed before the deployment, so it will not be deleted", r.HandlerID)
+	} else {
+		existing, err := getStackParameters(ctx, opts.AWSCfg, r.HandlerID)
+		if err != nil {
+			return err
+		}
+
+		if existing != nil {
+			clio.Infof("Deleting CloudFormation stack '%s'", r.HandlerID)
+
+			out, err := deployer.NewFromConfig(opts.AWSCfg).Delete(ctx, deployer.DeleteOpts{
+				StackName: r.HandlerID,
+			})
+			if err != nil {
+				return err
+			}
+			if out.FinalStatus != "DELETE_COMPLETE" {
+				return fmt.Errorf("failed to delete CloudFormation stack '%s': final status was %s", r.HandlerID, out.FinalStatus)
+			}
+		}
+	}
+
+	clio.Successf("Rolled back the deployment of Handler '%s'", r.HandlerID)
+
+	return rec.Remove()
+}
@@ -0,0 +1,355 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/AlecAivazis/survey/v2"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudformation"
+	"github.com/aws/aws-sdk-go-v2/service/cloudformation/types"
+	"github.com/common-fate/clio"
+	"github.com/common-fate/clio/clierr"
+	"github.com/common-fate/cloudform/deployer"
+	"github.com/common-fate/glide-cli/cmd/middleware"
+	"github.com/common-fate/glide-cli/pkg/client"
+	cfconfig "github.com/common-fate/glide-cli/pkg/config"
+	"github.com/common-fate/glide-cli/pkg/fmtconvert"
+	"github.com/common-fate/glide-cli/pkg/handlerstack"
+	"github.com/common-fate/glide-cli/pkg/secrets"
+	"github.com/common-fate/glide-cli/pkg/spec"
+	"github.com/common-fate/glide-cli/pkg/values"
+	"github.com/common-fate/provider-registry-sdk-go/pkg/bootstrapper"
+	"github.com/common-fate/provider-registry-sdk-go/pkg/providerregistrysdk"
+	registryclient "github.com/common-fate/provider-registry-sdk-go/pkg/registryclient"
+	"github.com/pkg/errors"
+	"github.com/urfave/cli/v2"
+)
+
+var upgradeCommand = cli.Command{
+	Name:        "upgrade",
+	Description: "Upgrade a deployed Handler to a different version of its provider. Existing config values are kept unless they are provided with --config, and you will only be prompted for config which is new in the target version. Target Groups linked to the Handler are updated to use the new version.",
+	Usage:       "Upgrade a Handler to a different provider version",
+	Flags: append([]cli.Flag{
+		&cli.StringFlag{Name: "handler-id", Required: true, Usage: "The Handler ID and CloudFormation stack name to upgrade"},
+		&cli.StringFlag{Name: "provider", Aliases: []string{"p"}, Required: true, Usage: "The provider version to upgrade to (for example, 'common-fate/aws@v0.5.0')"},
+		&cli.StringSliceFlag{Name: "config", Usage: "Provide config values in key=value format. Values for secret config keys are written to the secret backend"},
+		&cli.BoolFlag{Name: "confirm", Aliases: []string{"y"}, Usage: "Deploy the CloudFormation changes and update the linked Target Groups without prompting for confirmation"},
+	}, secrets.Flags()...),
+	Action: func(c *cli.Context) error {
+		ctx := c.Context
+
+		awsContext, err := middleware.AWSContextFromContext(ctx)
+		if err != nil {
+			return err
+		}
+
+		p, err := providerregistrysdk.ParseProvider(c.String("provider"))
+		if err != nil {
+			return err
+		}
+
+		configArgs, err := parseConfigArgs(c.StringSlice("config"))
+		if err != nil {
+			return err
+		}
+
+		configValues := map[string]values.ConfigValue{}
+		for k, v := range configArgs {
+			configValues[k] = values.ConfigValue{Value: v}
+		}
+
+		cfg, err := cfconfig.Load()
+		if err != nil {
+			return err
+		}
+
+		cf, err := client.FromConfig(ctx, cfg)
+		if err != nil {
+			return err
+		}
+
+		handlerID := c.String("handler-id")
+
+		handler, err := cf.AdminGetHandlerWithResponse(ctx, handlerID)
+		if err != nil {
+			return errors.Wrapf(err, "getting Handler %s", handlerID)
+		}
+
+		awsAccount, err := awsContext.Account(ctx)
+		if err != nil {
+			return err
+		}
+
+		if handler.JSON200.AwsAccount != awsAccount {
+			return clierr.New(fmt.Sprintf("Handler '%s' is deployed to account %s, but your AWS credentials are for account %s.", handlerID, handler.JSON200.AwsAccount, awsAccount), clierr.Info("Export credentials for the Handler's account and try again"))
+		}
+
+		// the stack is deployed in the region that the Handler is registered with,
+		// which may be different to the region of the current AWS credentials.
+		awsCfg := awsContext.Config.Copy()
+		awsCfg.Region = handler.JSON200.AwsRegion
+
+		registry, err := registryclient.New(ctx)
+		if err != nil {
+			return errors.Wrap(err, "configuring provider registry client")
+		}
+
+		clio.Infof("Retrieving provider details for '%s' from the Provider Registry...", p)
+		res, err := registry.GetProviderWithResponse(ctx, p.Publisher, p.Name, p.Version)
+		if err != nil {
+			return err
+		}
+		provider := res.JSON200
+
+		// check the provided config before deploying anything, in the same way as 'cf provider deploy'.
+		err = validateConfigValues(*provider, configValues)
+		if err != nil {
+			return err
+		}
+
+		stacks, err := cloudformation.NewFromConfig(awsCfg).DescribeStacks(ctx, &cloudformation.DescribeStacksInput{
+			StackName: &handlerID,
+		})
+		if err != nil {
+			return errors.Wrapf(err, "describing CloudFormation stack %s", handlerID)
+		}
+		if len(stacks.Stacks) == 0 {
+			return fmt.Errorf("could not find stack %s", handlerID)
+		}
+
+		existing := map[string]string{}
+		for _, param := range stacks.Stacks[0].Parameters {
+			existing[aws.ToString(param.ParameterKey)] = aws.ToString(param.ParameterValue)
+		}
+
+		current, ok := handlerstack.ProviderFromAssetPath(existing[handlerstack.ParamAssetPath])
+		if !ok {
+			return fmt.Errorf("could not determine the provider deployed by stack %s from its %s parameter", handlerID, handlerstack.ParamAssetPath)
+		}
+
+		if current.Publisher != p.Publisher || current.Name != p.Name {
+			return clierr.New(fmt.Sprintf("Handler '%s' runs %s and can't be upgraded to a different provider (%s).", handlerID, current, p), clierr.Info("Use 'cf provider deploy' to deploy a new provider"))
+		}
+
+		if current.Version == p.Version {
+			clio.Infof("Handler '%s' is already running %s, ensuring the deployment is up to date", handlerID, p)
+		} else {
+			clio.Infof("Upgrading Handler '%s' from %s to %s", handlerID, current, p)
+		}
+
+		// the Target Group changes are planned and confirmed before anything is changed,
+		// so that declining them doesn't leave the Handler half upgraded.
+		tgChanges, err := planTargetGroupUpgrade(ctx, cf, handlerID, p)
+		if err != nil {
+			return err
+		}
+
+		if len(tgChanges) > 0 {
+			clio.Infof("The Target Groups linked to Handler '%s' will be replaced to use %s, and relinked to their Handlers:", handlerID, p)
+			err = spec.PrintPlan(os.Stdout, tgChanges)
+			if err != nil {
+				return err
+			}
+
+			if !c.Bool("confirm") {
+				var confirm bool
+				err = survey.AskOne(&survey.Confirm{Message: "Apply these changes after the CloudFormation stack is updated?"}, &confirm)
+				if err != nil {
+					return err
+				}
+				if !confirm {
+					return clierr.New("Cancelled: Handler was not upgraded")
+				}
+			}
+		}
+
+		bs := bootstrapper.NewFromConfig(awsCfg)
+
+		bootstrapStackOutput, err := bs.GetOrDeployBootstrapBucket(ctx)
+		if err != nil {
+			return err
+		}
+
+		clio.Info("Copying provider assets from the registry to the bootstrap bucket...")
+		err = bs.CopyProviderFiles(ctx, *provider)
+		if err != nil {
+			return err
+		}
+		clio.Success("Provider assets copied to the bootstrap bucket")
+
+		secretWriter := secrets.New(awsCfg, secrets.OptsFromFlags(c))
+
+		parameters, err := upgradeParameters(ctx, upgradeParametersOpts{
+			Secrets:      secretWriter,
+			HandlerID:    handlerID,
+			Provider:     *provider,
+			Existing:     existing,
+			ConfigValues: configValues,
+		})
+		if err != nil {
+			return err
+		}
+
+		clio.Infof("Updating CloudFormation stack for Handler '%s'", handlerID)
+
+		out, err := deployer.NewFromConfig(awsCfg).Deploy(ctx, deployer.DeployOpts{
+			Template:  bootstrapStackOutput.CloudFormationURL(provider.Base()),
+			Params:    parameters,
+			StackName: handlerID,
+			Confirm:   c.Bool("confirm"),
+		})
+		if err != nil {
+			return err
+		}
+
+		if out.FinalStatus != "UPDATE_COMPLETE" && out.FinalStatus != "DEPLOY_SKIPPED" {
+			return fmt.Errorf("failed to update CloudFormation stack for Handler '%s': final status was %s", handlerID, out.FinalStatus)
+		}
+
+		if len(tgChanges) > 0 {
+			clio.Infof("Updating Target Groups linked to Handler '%s' to use %s", handlerID, p)
+			err = spec.Apply(ctx, cf, tgChanges)
+			if err != nil {
+				return err
+			}
+		}
+
+		err = waitForHealthyHandler(ctx, cf, handlerID)
+		if err != nil {
+			return err
+		}
+
+		clio.Successf("Handler '%s' is running %s", handlerID, p)
+		return nil
+	},
+}
+
+type upgradeParametersOpts struct {
+	Secrets   secrets.Writer
+	HandlerID string
+	Provider  providerregistrysdk.ProviderDetail
+	// Existing are the current parameters of the Handler stack.
+	Existing map[string]string
+	// ConfigValues are values provided with the --config flag.
+	ConfigValues map[string]values.ConfigValue
+}
+
+// upgradeParameters returns the CloudFormation parameters to upgrade a Handler stack.
+// Existing values are reused, and the user is prompted for config keys which are new
+// in the provider version being upgraded to.
+func upgradeParameters(ctx context.Context, opts upgradeParametersOpts) ([]types.Parameter, error) {
+	var parameters []types.Parameter
+
+	previous := func(key string) {
+		parameters = append(parameters, types.Parameter{
+			ParameterKey:     aws.String(key),
+			UsePreviousValue: aws.Bool(true),
+		})
+	}
+
+	config := opts.Provider.Schema.Config
+	if config != nil {
+		// sort keys alphabetically so they appear in a consistent order between CLI runs.
+		var keys []string
+		for k := range *config {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		for _, k := range keys {
+			v := (*config)[k]
+
+			paramName := fmtconvert.PascalCase(k)
+
+			var isSecret bool
+			if v.Secret != nil && *v.Secret {
+				isSecret = true
+				paramName += "Secret"
+			}
+
+			var paramVal string
+
+			if cv, ok := opts.ConfigValues[k]; ok {
+				// secrets are written to the secret backend, and the reference is used as the parameter value.
+				var err error
+				paramVal, err = resolveConfigValue(ctx, resolveConfigValueOpts{
+					Secrets:   opts.Secrets,
+					IsSecret:  isSecret,
+					Key:       k,
+					HandlerID: opts.HandlerID,
+					Provider:  opts.Provider,
+					Value:     cv,
+				})
+				if err != nil {
+					return nil, errors.Wrapf(err, "config value %s", k)
+				}
+			} else if _, ok := opts.Existing[paramName]; ok {
+				previous(paramName)
+				continue
+			} else {
+				clio.Infof("'%s' is a new config value in %s", k, opts.Provider)
+				var err error
+				paramVal, err = promptForConfig(ctx, promptForConfigOpts{
+					Secrets:   opts.Secrets,
+					IsSecret:  isSecret,
+					Key:       k,
+					HandlerID: opts.HandlerID,
+					Provider:  opts.Provider,
+					Schema:    v,
+				})
+				if err != nil {
+					return nil, err
+				}
+			}
+
+			parameters = append(parameters, types.Parameter{
+				ParameterKey:   aws.String(paramName),
+				ParameterValue: aws.String(paramVal),
+			})
+
+			// for secrets, paramVal is a reference to the secret rather than the secret value.
+			clio.Infof("Setting CloudFormation parameter %s=%s", paramName, paramVal)
+		}
+	}
+
+	previous(handlerstack.ParamCommonFateAWSAccountID)
+	previous(handlerstack.ParamBootstrapBucketName)
+	previous(handlerstack.ParamHandlerID)
+
+	parameters = append(parameters, types.Parameter{
+		ParameterKey:   aws.String(handlerstack.ParamAssetPath),
+		ParameterValue: aws.String(handlerstack.AssetPath(opts.Provider.Base())),
+	})
+
+	return parameters, nil
+}
+
+// planTargetGroupUpgrade returns the changes which update the provider version of the Target Groups
+// linked to a Handler. Target Groups can't be updated through the API, so they are replaced and relinked.
+func planTargetGroupUpgrade(ctx context.Context, cf *client.Client, handlerID string, p providerregistrysdk.Provider) ([]spec.Change, error) {
+	current, err := spec.Current(ctx, cf)
+	if err != nil {
+		return nil, err
+	}
+
+	linked := map[string]bool{}
+	for _, l := range current.Links {
+		if l.Handler == handlerID {
+			linked[l.TargetGroup] = true
+		}
+	}
+
+	desired := current.Copy()
+	for _, tg := range current.TargetGroups {
+		from, err := providerregistrysdk.ParseProvider(tg.Provider)
+		if err == nil && linked[tg.ID] && from.Publisher == p.Publisher && from.Name == p.Name {
+			tg.Provider = p.String()
+			desired.PutTargetGroup(tg)
+		}
+	}
+
+	return spec.Plan(desired, *current), nil
+}
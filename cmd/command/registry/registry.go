@@ -0,0 +1,12 @@
+package registry
+
+import "github.com/urfave/cli/v2"
+
+var Command = cli.Command{
+	Name:        "registry",
+	Description: "Run a local Provider Registry",
+	Usage:       "Run a local Provider Registry",
+	Subcommands: []*cli.Command{
+		&ServeCommand,
+	},
+}
@@ -0,0 +1,45 @@
+package registry
+
+import (
+	"net/http"
+	"os"
+
+	"github.com/common-fate/clio"
+	"github.com/common-fate/clio/clierr"
+	"github.com/common-fate/glide-cli/pkg/localregistry"
+	"github.com/urfave/cli/v2"
+)
+
+var ServeCommand = cli.Command{
+	Name:        "serve",
+	Description: "Serve Providers from a local directory using the Provider Registry API. Useful for testing provider flows offline and for hosting private providers.",
+	Usage:       "Serve Providers from a local directory",
+	Flags: []cli.Flag{
+		&cli.StringFlag{Name: "dir", Required: true, Usage: "The directory containing providers, in the format '<publisher>/<name>/<version>/provider.json'"},
+		&cli.StringFlag{Name: "addr", Value: "localhost:9500", Usage: "The address to listen on"},
+	},
+	Action: func(c *cli.Context) error {
+		dir := c.String("dir")
+
+		info, err := os.Stat(dir)
+		if err != nil {
+			return clierr.New("Could not open the provider directory.", clierr.Debug(err.Error()))
+		}
+		if !info.IsDir() {
+			return clierr.New("The --dir flag must be a directory: " + dir)
+		}
+
+		addr := c.String("addr")
+		u := "http://" + addr
+
+		clio.Infof("Serving providers from %s on %s", dir, u)
+		clio.Infof("To use this registry with the Common Fate CLI, run:\nexport COMMON_FATE_PROVIDER_REGISTRY_URL=%s\nexport COMMON_FATE_PROVIDER_REGISTRY_S3_URL=%s%s", u, u, localregistry.AssetsPrefix)
+
+		server := &http.Server{
+			Addr:    addr,
+			Handler: localregistry.New(dir).Handler(),
+		}
+
+		return server.ListenAndServe()
+	},
+}
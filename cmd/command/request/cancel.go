@@ -0,0 +1,57 @@
+package request
+
+import (
+	"fmt"
+
+	"github.com/common-fate/clio"
+	"github.com/common-fate/clio/clierr"
+	"github.com/common-fate/common-fate/pkg/types"
+	"github.com/common-fate/glide-cli/pkg/client"
+	"github.com/common-fate/glide-cli/pkg/config"
+	"github.com/urfave/cli/v2"
+)
+
+var CancelCommand = cli.Command{
+	Name:        "cancel",
+	Description: "Cancel a pending Access Request",
+	Usage:       "Cancel a pending Access Request",
+	ArgsUsage:   "<id>",
+	Action: func(c *cli.Context) error {
+		ctx := c.Context
+
+		id := c.Args().First()
+		if id == "" {
+			return clierr.New("usage: cf request cancel [id]")
+		}
+
+		cfg, err := config.Load()
+		if err != nil {
+			return err
+		}
+
+		cf, err := client.FromConfig(ctx, cfg)
+		if err != nil {
+			return err
+		}
+
+		res, err := cf.UserGetRequestWithResponse(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		// check the status first so that we can give a clearer error
+		// than the API does when the request can't be cancelled.
+		if res.JSON200.Status != types.RequestStatusPENDING {
+			return clierr.New(fmt.Sprintf("Request '%s' can't be cancelled because it is %s. Only pending requests can be cancelled.", id, res.JSON200.Status))
+		}
+
+		_, err = cf.UserCancelRequestWithResponse(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		clio.Successf("Cancelled request %s", id)
+
+		return nil
+	},
+}
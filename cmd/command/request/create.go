@@ -0,0 +1,231 @@
+package request
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/AlecAivazis/survey/v2"
+	"github.com/common-fate/clio/clierr"
+	"github.com/common-fate/common-fate/pkg/types"
+	"github.com/common-fate/glide-cli/pkg/client"
+	"github.com/common-fate/glide-cli/pkg/config"
+	"github.com/common-fate/glide-cli/pkg/output"
+	"github.com/urfave/cli/v2"
+)
+
+var CreateCommand = cli.Command{
+	Name:        "create",
+	Description: "Request access using an Access Rule",
+	Usage:       "Request access using an Access Rule",
+	Flags: []cli.Flag{
+		&cli.StringFlag{Name: "rule", Aliases: []string{"r"}, Required: true, Usage: "The ID of the Access Rule to request access with"},
+		&cli.StringSliceFlag{Name: "with", Aliases: []string{"w"}, Usage: "An argument value in key=value format, where the value is the option value or label (for example, 'accountId=123456789012'). Can be repeated to request multiple values"},
+		&cli.StringFlag{Name: "reason", Usage: "The reason for requesting access"},
+		&cli.DurationFlag{Name: "duration", Aliases: []string{"d"}, Usage: "How long to request access for (defaults to the maximum duration allowed by the Access Rule)"},
+		output.Flag(),
+	},
+	Action: func(c *cli.Context) error {
+		ctx := c.Context
+
+		format, err := output.Format(c)
+		if err != nil {
+			return err
+		}
+
+		with, err := parseWith(c.StringSlice("with"))
+		if err != nil {
+			return err
+		}
+
+		cfg, err := config.Load()
+		if err != nil {
+			return err
+		}
+
+		cf, err := client.FromConfig(ctx, cfg)
+		if err != nil {
+			return err
+		}
+
+		body, err := buildRequest(ctx, cf, requestOpts{
+			RuleID:   c.String("rule"),
+			With:     with,
+			Duration: c.Duration("duration"),
+			Reason:   c.String("reason"),
+		})
+		if err != nil {
+			return err
+		}
+
+		res, err := cf.UserCreateRequestWithResponse(ctx, body)
+		if err != nil {
+			return err
+		}
+
+		if format == output.JSON {
+			return output.PrintJSON(os.Stdout, res.JSON200.Requests)
+		}
+
+		return printRequests(os.Stdout, res.JSON200.Requests)
+	},
+}
+
+// requestOpts are the values used to build an Access Request.
+type requestOpts struct {
+	RuleID string
+	// With maps argument keys to option values or labels.
+	With map[string][]string
+	// Duration defaults to the maximum duration allowed by the Access Rule if empty.
+	Duration time.Duration
+	Reason   string
+}
+
+// parseWith parses --with flag values in key=value format.
+func parseWith(args []string) (map[string][]string, error) {
+	with := map[string][]string{}
+	for _, arg := range args {
+		parts := strings.SplitN(arg, "=", 2) // args are in key=value format
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("invalid argument (expected format is --with key=value): %s", arg)
+		}
+		with[parts[0]] = append(with[parts[0]], parts[1])
+	}
+	return with, nil
+}
+
+// buildRequest validates the request options against the Access Rule
+// and builds the body used to create the request.
+func buildRequest(ctx context.Context, cf *client.Client, opts requestOpts) (types.UserCreateRequestJSONRequestBody, error) {
+	rule, err := cf.UserGetAccessRuleWithResponse(ctx, opts.RuleID)
+	if err != nil {
+		return types.UserCreateRequestJSONRequestBody{}, err
+	}
+	if !rule.JSON200.CanRequest {
+		return types.UserCreateRequestJSONRequestBody{}, clierr.New(fmt.Sprintf("You don't have permission to request access using Access Rule '%s'.", opts.RuleID))
+	}
+
+	args, err := resolveArguments(*rule.JSON200, opts.With)
+	if err != nil {
+		return types.UserCreateRequestJSONRequestBody{}, err
+	}
+
+	maxDuration := time.Duration(rule.JSON200.TimeConstraints.MaxDurationSeconds) * time.Second
+	duration := opts.Duration
+	if duration == 0 {
+		duration = maxDuration
+	}
+	if duration > maxDuration {
+		return types.UserCreateRequestJSONRequestBody{}, clierr.New(fmt.Sprintf("The requested duration (%s) is longer than the maximum allowed by the Access Rule (%s).", duration, maxDuration))
+	}
+
+	body := types.UserCreateRequestJSONRequestBody{
+		AccessRuleId: opts.RuleID,
+		Timing: types.RequestTiming{
+			DurationSeconds: int(duration.Seconds()),
+		},
+	}
+	if len(args.AdditionalProperties) > 0 {
+		body.With = &types.CreateRequestWithSubRequest{args}
+	}
+	if opts.Reason != "" {
+		body.Reason = &opts.Reason
+	}
+	return body, nil
+}
+
+// resolveArguments matches the provided argument values against the options
+// available in the Access Rule. Values may be provided as either the option value
+// or the option label. Arguments with a single option are selected automatically,
+// and the user is prompted to select any other arguments which weren't provided.
+func resolveArguments(rule types.RequestAccessRule, with map[string][]string) (types.CreateRequestWith, error) {
+	ruleArgs := rule.Target.Arguments.AdditionalProperties
+
+	var keys []string
+	for k := range ruleArgs {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for k := range with {
+		if _, ok := ruleArgs[k]; !ok {
+			return types.CreateRequestWith{}, clierr.New(fmt.Sprintf("Access Rule '%s' doesn't have an argument '%s'.", rule.ID, k), clierr.Infof("Available arguments: %s", joinOrNone(keys)))
+		}
+	}
+
+	resolved := types.CreateRequestWith{AdditionalProperties: map[string][]string{}}
+
+	for _, k := range keys {
+		arg := ruleArgs[k]
+
+		values, ok := with[k]
+		if !ok {
+			v, err := selectArgument(k, arg)
+			if err != nil {
+				return types.CreateRequestWith{}, err
+			}
+			values = []string{v}
+		}
+
+		for _, v := range values {
+			opt, ok := findOption(arg.Options, v)
+			if !ok {
+				return types.CreateRequestWith{}, clierr.New(fmt.Sprintf("'%s' is not a valid option for argument '%s'.", v, k), clierr.Infof("Available options: %s", formatOptions(arg.Options)))
+			}
+			resolved.AdditionalProperties[k] = append(resolved.AdditionalProperties[k], opt.Value)
+		}
+	}
+
+	return resolved, nil
+}
+
+// selectArgument selects the option for an argument which wasn't provided with the --with flag.
+func selectArgument(key string, arg types.RequestArgument) (string, error) {
+	if len(arg.Options) == 0 {
+		return "", clierr.New(fmt.Sprintf("Argument '%s' doesn't have any options available to request.", key))
+	}
+	if len(arg.Options) == 1 {
+		return arg.Options[0].Value, nil
+	}
+
+	var labels []string
+	values := map[string]string{}
+	for _, o := range arg.Options {
+		labels = append(labels, o.Label)
+		values[o.Label] = o.Value
+	}
+
+	var selected string
+	err := survey.AskOne(&survey.Select{Message: "Select " + arg.Title, Options: labels}, &selected)
+	if err != nil {
+		return "", err
+	}
+	return values[selected], nil
+}
+
+// findOption finds the option matching the value or label.
+// Values take priority over labels if they overlap.
+func findOption(options []types.WithOption, v string) (types.WithOption, bool) {
+	for _, o := range options {
+		if o.Value == v {
+			return o, true
+		}
+	}
+	for _, o := range options {
+		if o.Label == v {
+			return o, true
+		}
+	}
+	return types.WithOption{}, false
+}
+
+func formatOptions(options []types.WithOption) string {
+	var s []string
+	for _, o := range options {
+		s = append(s, fmt.Sprintf("%s (%s)", o.Value, o.Label))
+	}
+	return joinOrNone(s)
+}
@@ -0,0 +1,248 @@
+package request
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"time"
+
+	"github.com/common-fate/clio"
+	"github.com/common-fate/clio/clierr"
+	"github.com/common-fate/common-fate/pkg/types"
+	"github.com/common-fate/glide-cli/pkg/client"
+	"github.com/common-fate/glide-cli/pkg/config"
+	"github.com/pkg/errors"
+	"github.com/urfave/cli/v2"
+)
+
+var ExecCommand = cli.Command{
+	Name:        "exec",
+	Description: "Request access using an Access Rule, wait for the grant to become active, and then run a command. An existing pending or active request with the same rule and arguments is reused if there is one, as long as its access lasts for at least the requested duration. The request ID is available to the command in the CF_REQUEST_ID environment variable.",
+	Usage:       "Run a command while an Access Request is active",
+	ArgsUsage:   "-- <command> [args...]",
+	Flags: []cli.Flag{
+		&cli.StringFlag{Name: "rule", Aliases: []string{"r"}, Required: true, Usage: "The ID of the Access Rule to request access with"},
+		&cli.StringSliceFlag{Name: "with", Aliases: []string{"w"}, Usage: "An argument value in key=value format, where the value is the option value or label (for example, 'accountId=123456789012')"},
+		&cli.StringFlag{Name: "reason", Usage: "The reason for requesting access"},
+		&cli.DurationFlag{Name: "duration", Aliases: []string{"d"}, Usage: "How long to request access for (defaults to the maximum duration allowed by the Access Rule)"},
+		&cli.DurationFlag{Name: "timeout", Value: time.Hour, Usage: "How long to wait for the grant to become active (0 waits forever)"},
+		&cli.BoolFlag{Name: "new", Usage: "Always create a new request rather than reusing an existing one"},
+		&cli.BoolFlag{Name: "revoke", Usage: "Revoke the grant when the command exits (only applies to requests created by this command)"},
+	},
+	Action: func(c *cli.Context) error {
+		ctx := c.Context
+
+		args := c.Args().Slice()
+		if len(args) == 0 {
+			return clierr.New("usage: cf exec --rule [id] -- [command] [args...]")
+		}
+
+		with, err := parseWith(c.StringSlice("with"))
+		if err != nil {
+			return err
+		}
+
+		cfg, err := config.Load()
+		if err != nil {
+			return err
+		}
+
+		cf, err := client.FromConfig(ctx, cfg)
+		if err != nil {
+			return err
+		}
+
+		body, err := buildRequest(ctx, cf, requestOpts{
+			RuleID:   c.String("rule"),
+			With:     with,
+			Duration: c.Duration("duration"),
+			Reason:   c.String("reason"),
+		})
+		if err != nil {
+			return err
+		}
+
+		if body.With != nil {
+			for k, v := range (*body.With)[0].AdditionalProperties {
+				if len(v) != 1 {
+					return clierr.New(fmt.Sprintf("cf exec requires a single value for each argument, but %d values were provided for '%s'.", len(v), k))
+				}
+			}
+		}
+
+		var requestID string
+		if !c.Bool("new") {
+			requestID, err = findReusableRequest(ctx, cf, body)
+			if err != nil {
+				return err
+			}
+		}
+
+		created := requestID == ""
+
+		if created {
+			res, err := cf.UserCreateRequestWithResponse(ctx, body)
+			if err != nil {
+				return err
+			}
+			if len(res.JSON200.Requests) != 1 {
+				return fmt.Errorf("expected 1 request to be created but got %d", len(res.JSON200.Requests))
+			}
+			requestID = res.JSON200.Requests[0].ID
+			clio.Infof("Created request %s", requestID)
+		} else {
+			clio.Infof("Reusing existing request %s (use --new to create a new request)", requestID)
+		}
+
+		if created && c.Bool("revoke") {
+			defer func() {
+				// use a new context, as the command context may have been cancelled.
+				err := endRequest(context.Background(), cf, requestID)
+				if err != nil {
+					clio.Errorf("Error revoking request %s: %s", requestID, err)
+				}
+			}()
+		}
+
+		_, err = waitForRequest(ctx, cf, watchOpts{
+			ID:      requestID,
+			Until:   untilActive,
+			Timeout: c.Duration("timeout"),
+		})
+		if err != nil {
+			return exitOnWatchError(err)
+		}
+
+		cmd := exec.Command(args[0], args[1:]...)
+		cmd.Stdin = os.Stdin
+		cmd.Stdout = os.Stdout
+		cmd.Stderr = os.Stderr
+		cmd.Env = append(os.Environ(), "CF_REQUEST_ID="+requestID)
+
+		// the child process receives interrupts from the terminal directly,
+		// so ignore them here to make sure the grant is revoked after the command exits.
+		signal.Ignore(os.Interrupt)
+		defer signal.Reset(os.Interrupt)
+
+		err = cmd.Run()
+
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			return cli.Exit("", exitErr.ExitCode())
+		}
+		return err
+	},
+}
+
+// findReusableRequest finds a pending or active request for the same Access Rule
+// and arguments as the request body. It returns an empty string if there isn't one.
+//
+// Only upcoming requests are considered, and requests whose access would end before
+// the requested duration are skipped, so that the command doesn't start with a grant
+// which expires partway through.
+func findReusableRequest(ctx context.Context, cf *client.Client, body types.UserCreateRequestJSONRequestBody) (string, error) {
+	requests, err := listUpcomingRequests(ctx, cf)
+	if err != nil {
+		return "", err
+	}
+
+	now := time.Now()
+	want := time.Duration(body.Timing.DurationSeconds) * time.Second
+
+	for _, r := range requests {
+		if r.AccessRuleId != body.AccessRuleId || remainingDuration(r, now) < want {
+			continue
+		}
+		// the arguments are only returned when getting a request.
+		res, err := cf.UserGetRequestWithResponse(ctx, r.ID)
+		if err != nil {
+			return "", err
+		}
+		state := requestState(*res.JSON200)
+		if state != statePending && state != stateApproved && state != stateActive {
+			continue
+		}
+		if argumentsMatch(res.JSON200.Arguments, body.With) {
+			return r.ID, nil
+		}
+	}
+	return "", nil
+}
+
+// listUpcomingRequests lists the user's requests which are pending, or which have a grant that hasn't ended.
+func listUpcomingRequests(ctx context.Context, cf *client.Client) ([]types.Request, error) {
+	var params types.UserListRequestsUpcomingParams
+	requests := []types.Request{}
+	for {
+		res, err := cf.UserListRequestsUpcomingWithResponse(ctx, &params)
+		if err != nil {
+			return nil, err
+		}
+		requests = append(requests, res.JSON200.Requests...)
+
+		if res.JSON200.Next == nil || *res.JSON200.Next == "" {
+			return requests, nil
+		}
+		params.NextToken = res.JSON200.Next
+	}
+}
+
+// remainingDuration returns how long access from a request will last, from now or
+// from the start of the grant if it starts in the future. For requests which haven't
+// been approved yet, this is the requested duration.
+func remainingDuration(r types.Request, now time.Time) time.Duration {
+	if r.Grant == nil {
+		return time.Duration(r.Timing.DurationSeconds) * time.Second
+	}
+	start := r.Grant.Start
+	if now.After(start) {
+		start = now
+	}
+	return r.Grant.End.Sub(start)
+}
+
+// argumentsMatch returns true if the arguments of an existing request
+// are the same as the arguments in a request body.
+func argumentsMatch(existing types.RequestDetail_Arguments, with *types.CreateRequestWithSubRequest) bool {
+	want := map[string][]string{}
+	if with != nil && len(*with) > 0 {
+		want = (*with)[0].AdditionalProperties
+	}
+	if len(existing.AdditionalProperties) != len(want) {
+		return false
+	}
+	for k, v := range want {
+		got, ok := existing.AdditionalProperties[k]
+		if !ok || len(v) != 1 || got.Value != v[0] {
+			return false
+		}
+	}
+	return true
+}
+
+// endRequest revokes the grant for a request,
+// or cancels the request if it hasn't been approved yet.
+func endRequest(ctx context.Context, cf *client.Client, id string) error {
+	res, err := cf.UserGetRequestWithResponse(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	switch requestState(*res.JSON200) {
+	case statePending:
+		_, err = cf.UserCancelRequestWithResponse(ctx, id)
+		if err != nil {
+			return err
+		}
+		clio.Infof("Cancelled request %s", id)
+	case stateApproved, stateActive:
+		_, err = cf.UserRevokeRequestWithResponse(ctx, id)
+		if err != nil {
+			return err
+		}
+		clio.Infof("Revoked request %s", id)
+	}
+	return nil
+}
@@ -0,0 +1,48 @@
+package request
+
+import (
+	"testing"
+	"time"
+
+	"github.com/common-fate/common-fate/pkg/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRemainingDuration(t *testing.T) {
+	now := time.Date(2023, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	type testcase struct {
+		name    string
+		request types.Request
+		want    time.Duration
+	}
+
+	testcases := []testcase{
+		{
+			name:    "pending uses the requested duration",
+			request: types.Request{Timing: types.RequestTiming{DurationSeconds: 3600}},
+			want:    time.Hour,
+		},
+		{
+			name:    "active grant",
+			request: types.Request{Grant: &types.Grant{Start: now.Add(-time.Hour), End: now.Add(time.Minute * 10)}},
+			want:    time.Minute * 10,
+		},
+		{
+			name:    "scheduled grant",
+			request: types.Request{Grant: &types.Grant{Start: now.Add(time.Hour), End: now.Add(time.Hour * 3)}},
+			want:    time.Hour * 2,
+		},
+		{
+			name:    "ended grant",
+			request: types.Request{Grant: &types.Grant{Start: now.Add(-time.Hour * 2), End: now.Add(-time.Hour)}},
+			want:    -time.Hour,
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.want, remainingDuration(tc.request, now))
+		})
+	}
+}
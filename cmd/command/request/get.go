@@ -0,0 +1,101 @@
+package request
+
+import (
+	"os"
+	"sort"
+
+	"github.com/common-fate/clio"
+	"github.com/common-fate/clio/clierr"
+	"github.com/common-fate/common-fate/pkg/types"
+	"github.com/common-fate/glide-cli/pkg/client"
+	"github.com/common-fate/glide-cli/pkg/config"
+	"github.com/common-fate/glide-cli/pkg/output"
+	"github.com/common-fate/glide-cli/pkg/table"
+	"github.com/urfave/cli/v2"
+)
+
+var GetCommand = cli.Command{
+	Name:        "get",
+	Description: "Show the details of an Access Request",
+	Usage:       "Show the details of an Access Request",
+	ArgsUsage:   "<id>",
+	Flags: []cli.Flag{
+		output.Flag(),
+	},
+	Action: func(c *cli.Context) error {
+		ctx := c.Context
+
+		id := c.Args().First()
+		if id == "" {
+			return clierr.New("usage: cf request get [id]")
+		}
+
+		format, err := output.Format(c)
+		if err != nil {
+			return err
+		}
+
+		cfg, err := config.Load()
+		if err != nil {
+			return err
+		}
+
+		cf, err := client.FromConfig(ctx, cfg)
+		if err != nil {
+			return err
+		}
+
+		res, err := cf.UserGetRequestWithResponse(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		if format == output.JSON {
+			return output.PrintJSON(os.Stdout, res.JSON200)
+		}
+
+		return printRequestDetail(*res.JSON200)
+	},
+}
+
+// printRequestDetail prints the details of a request, its arguments, and its grant.
+func printRequestDetail(r types.RequestDetail) error {
+	reason := "-"
+	if r.Reason != nil && *r.Reason != "" {
+		reason = *r.Reason
+	}
+
+	w := table.New(os.Stdout)
+	w.Columns("ID", "Rule", "Status", "Requestor", "Duration", "Requested At", "Reason")
+	w.Row(r.ID, r.AccessRule.Name, string(r.Status), r.Requestor, formatDuration(r.Timing.DurationSeconds), formatTime(r.RequestedAt), reason)
+	err := w.Flush()
+	if err != nil {
+		return err
+	}
+
+	clio.NewLine()
+	clio.Log("Arguments")
+	w.Columns("Argument", "Title", "Label", "Value")
+	args := r.Arguments.AdditionalProperties
+	var keys []string
+	for k := range args {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		w.Row(k, args[k].Title, args[k].Label, args[k].Value)
+	}
+	err = w.Flush()
+	if err != nil {
+		return err
+	}
+
+	if r.Grant != nil {
+		clio.NewLine()
+		clio.Log("Grant")
+		w.Columns("Status", "Start", "End")
+		w.Row(string(r.Grant.Status), formatTime(r.Grant.Start), formatTime(r.Grant.End))
+	}
+
+	return w.Flush()
+}
@@ -0,0 +1,100 @@
+package request
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/common-fate/common-fate/pkg/types"
+	"github.com/common-fate/glide-cli/pkg/client"
+	"github.com/common-fate/glide-cli/pkg/config"
+	"github.com/common-fate/glide-cli/pkg/output"
+	"github.com/urfave/cli/v2"
+)
+
+// requestStatuses are the values accepted by the --status flag.
+var requestStatuses = []string{
+	string(types.RequestStatusPENDING),
+	string(types.RequestStatusAPPROVED),
+	string(types.RequestStatusDECLINED),
+	string(types.RequestStatusCANCELLED),
+}
+
+var ListCommand = cli.Command{
+	Name:        "list",
+	Aliases:     []string{"ls"},
+	Description: "List your Access Requests",
+	Usage:       "List your Access Requests",
+	Flags: []cli.Flag{
+		&cli.StringFlag{Name: "status", Usage: "Only show requests with the given status (one of " + strings.Join(requestStatuses, ", ") + ")"},
+		output.Flag(),
+	},
+	Action: func(c *cli.Context) error {
+		ctx := c.Context
+
+		format, err := output.Format(c)
+		if err != nil {
+			return err
+		}
+
+		params := types.UserListRequestsParams{}
+
+		if s := c.String("status"); s != "" {
+			status := types.UserListRequestsParamsStatus(strings.ToUpper(s))
+			if !contains(requestStatuses, string(status)) {
+				return fmt.Errorf("invalid status '%s': supported statuses are %s", s, strings.Join(requestStatuses, ", "))
+			}
+			params.Status = &status
+		}
+
+		cfg, err := config.Load()
+		if err != nil {
+			return err
+		}
+
+		cf, err := client.FromConfig(ctx, cfg)
+		if err != nil {
+			return err
+		}
+
+		requests, err := listRequests(ctx, cf, params)
+		if err != nil {
+			return err
+		}
+
+		if format == output.JSON {
+			return output.PrintJSON(os.Stdout, requests)
+		}
+
+		return printRequests(os.Stdout, requests)
+	},
+}
+
+// listRequests lists all pages of requests matching the params.
+func listRequests(ctx context.Context, cf *client.Client, params types.UserListRequestsParams) ([]types.Request, error) {
+	requests := []types.Request{}
+	for {
+		res, err := cf.UserListRequestsWithResponse(ctx, &params)
+		if err != nil {
+			return nil, err
+		}
+		requests = append(requests, res.JSON200.Requests...)
+
+		if res.JSON200.Next == nil || *res.JSON200.Next == "" {
+			return requests, nil
+		}
+		params.NextToken = res.JSON200.Next
+	}
+}
+
+// utility function to check if the string belongs to the slice.
+func contains(s []string, str string) bool {
+	for _, v := range s {
+		if v == str {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,61 @@
+package request
+
+import (
+	"io"
+	"strings"
+	"time"
+
+	"github.com/common-fate/common-fate/pkg/types"
+	"github.com/common-fate/glide-cli/pkg/table"
+	"github.com/urfave/cli/v2"
+)
+
+var Command = cli.Command{
+	Name:        "request",
+	Aliases:     []string{"requests"},
+	Description: "Request access and manage your Access Requests",
+	Usage:       "Request access and manage your Access Requests",
+	Subcommands: []*cli.Command{
+		&CreateCommand,
+		&ListCommand,
+		&GetCommand,
+		&CancelCommand,
+		&WatchCommand,
+		&SaveCommand,
+		&SavedCommand,
+		&AgainCommand,
+	},
+}
+
+// printRequests prints a table of Access Requests to w.
+func printRequests(w io.Writer, requests []types.Request) error {
+	tbl := table.New(w)
+	tbl.Columns("ID", "Rule", "Status", "Grant", "Duration", "Requested At", "Reason")
+	for _, r := range requests {
+		grant := "-"
+		if r.Grant != nil {
+			grant = string(r.Grant.Status)
+		}
+		reason := "-"
+		if r.Reason != nil && *r.Reason != "" {
+			reason = *r.Reason
+		}
+		tbl.Row(r.ID, r.AccessRuleId, string(r.Status), grant, formatDuration(r.Timing.DurationSeconds), formatTime(r.RequestedAt), reason)
+	}
+	return tbl.Flush()
+}
+
+func formatDuration(seconds int) string {
+	return (time.Duration(seconds) * time.Second).String()
+}
+
+func formatTime(t time.Time) string {
+	return t.Local().Format("2006-01-02 15:04:05")
+}
+
+func joinOrNone(s []string) string {
+	if len(s) == 0 {
+		return "-"
+	}
+	return strings.Join(s, ", ")
+}
@@ -0,0 +1,183 @@
+package request
+
+import (
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/common-fate/clio"
+	"github.com/common-fate/clio/clierr"
+	"github.com/common-fate/common-fate/pkg/types"
+	"github.com/common-fate/glide-cli/pkg/client"
+	"github.com/common-fate/glide-cli/pkg/config"
+	"github.com/common-fate/glide-cli/pkg/output"
+	"github.com/common-fate/glide-cli/pkg/table"
+	"github.com/urfave/cli/v2"
+)
+
+var ReviewCommand = cli.Command{
+	Name:        "review",
+	Description: "Review Access Requests that you are an approver for",
+	Usage:       "Review Access Requests that you are an approver for",
+	Subcommands: []*cli.Command{
+		&ReviewListCommand,
+		&ApproveCommand,
+		&DeclineCommand,
+	},
+}
+
+var ReviewListCommand = cli.Command{
+	Name:        "list",
+	Aliases:     []string{"ls"},
+	Description: "List Access Requests awaiting your review",
+	Usage:       "List Access Requests awaiting your review",
+	Flags: []cli.Flag{
+		output.Flag(),
+	},
+	Action: func(c *cli.Context) error {
+		ctx := c.Context
+
+		format, err := output.Format(c)
+		if err != nil {
+			return err
+		}
+
+		cfg, err := config.Load()
+		if err != nil {
+			return err
+		}
+
+		cf, err := client.FromConfig(ctx, cfg)
+		if err != nil {
+			return err
+		}
+
+		status := types.UserListRequestsParamsStatus(types.RequestStatusPENDING)
+		requests, err := listRequests(ctx, cf, types.UserListRequestsParams{
+			Status:   &status,
+			Reviewer: aws.Bool(true),
+		})
+		if err != nil {
+			return err
+		}
+
+		// the list API doesn't include the request arguments or Access Rule name,
+		// so fetch the details of each request to give reviewers the full context.
+		details := []types.RequestDetail{}
+		for _, r := range requests {
+			res, err := cf.UserGetRequestWithResponse(ctx, r.ID)
+			if err != nil {
+				return err
+			}
+			details = append(details, *res.JSON200)
+		}
+
+		if format == output.JSON {
+			return output.PrintJSON(os.Stdout, details)
+		}
+
+		tbl := table.New(os.Stdout)
+		tbl.Columns("ID", "Requestor", "Rule", "Arguments", "Duration", "Requested At", "Reason")
+		for _, r := range details {
+			reason := "-"
+			if r.Reason != nil && *r.Reason != "" {
+				reason = *r.Reason
+			}
+			tbl.Row(r.ID, r.Requestor, r.AccessRule.Name, formatArguments(r.Arguments), formatDuration(r.Timing.DurationSeconds), formatTime(r.RequestedAt), reason)
+		}
+		return tbl.Flush()
+	},
+}
+
+var ApproveCommand = cli.Command{
+	Name:        "approve",
+	Description: "Approve an Access Request",
+	Usage:       "Approve an Access Request",
+	ArgsUsage:   "<id>",
+	Flags: []cli.Flag{
+		&cli.StringFlag{Name: "comment", Aliases: []string{"m"}, Usage: "A comment to add to the review"},
+		output.Flag(),
+	},
+	Action: func(c *cli.Context) error {
+		return review(c, types.APPROVED)
+	},
+}
+
+var DeclineCommand = cli.Command{
+	Name:        "decline",
+	Description: "Decline an Access Request",
+	Usage:       "Decline an Access Request",
+	ArgsUsage:   "<id>",
+	Flags: []cli.Flag{
+		&cli.StringFlag{Name: "comment", Aliases: []string{"m"}, Usage: "A comment to add to the review"},
+		output.Flag(),
+	},
+	Action: func(c *cli.Context) error {
+		return review(c, types.DECLINED)
+	},
+}
+
+// review submits a review decision for the request ID provided as the first argument.
+func review(c *cli.Context, decision types.ReviewDecision) error {
+	ctx := c.Context
+
+	id := c.Args().First()
+	if id == "" {
+		return clierr.New(fmt.Sprintf("usage: %s [id]", c.Command.HelpName))
+	}
+
+	format, err := output.Format(c)
+	if err != nil {
+		return err
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+
+	cf, err := client.FromConfig(ctx, cfg)
+	if err != nil {
+		return err
+	}
+
+	body := types.UserReviewRequestJSONRequestBody{
+		Decision: decision,
+	}
+	if comment := c.String("comment"); comment != "" {
+		body.Comment = &comment
+	}
+
+	res, err := cf.UserReviewRequestWithResponse(ctx, id, body)
+	if err != nil {
+		return err
+	}
+
+	if format == output.JSON {
+		return output.PrintJSON(os.Stdout, res.JSON200.Request)
+	}
+
+	if decision == types.APPROVED {
+		clio.Successf("Approved request %s", id)
+	} else {
+		clio.Successf("Declined request %s", id)
+	}
+
+	return nil
+}
+
+// formatArguments prints request arguments in 'key=label' format, sorted by key.
+func formatArguments(args types.RequestDetail_Arguments) string {
+	var keys []string
+	for k := range args.AdditionalProperties {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var s []string
+	for _, k := range keys {
+		s = append(s, k+"="+args.AdditionalProperties[k].Label)
+	}
+	return joinOrNone(s)
+}
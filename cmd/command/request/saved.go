@@ -0,0 +1,210 @@
+package request
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/common-fate/clio"
+	"github.com/common-fate/clio/clierr"
+	"github.com/common-fate/glide-cli/pkg/client"
+	"github.com/common-fate/glide-cli/pkg/config"
+	"github.com/common-fate/glide-cli/pkg/output"
+	"github.com/common-fate/glide-cli/pkg/table"
+	"github.com/urfave/cli/v2"
+)
+
+var SaveCommand = cli.Command{
+	Name:        "save",
+	Description: "Save an Access Request template to the CLI config, so that it can be requested again with 'cf request again [name]'",
+	Usage:       "Save an Access Request template",
+	ArgsUsage:   "<name>",
+	Flags: []cli.Flag{
+		&cli.StringFlag{Name: "rule", Aliases: []string{"r"}, Required: true, Usage: "The ID of the Access Rule to request access with"},
+		&cli.StringSliceFlag{Name: "with", Aliases: []string{"w"}, Usage: "An argument value in key=value format, where the value is the option value or label (for example, 'accountId=123456789012')"},
+		&cli.StringFlag{Name: "reason", Usage: "The reason for requesting access"},
+		&cli.DurationFlag{Name: "duration", Aliases: []string{"d"}, Usage: "How long to request access for (defaults to the maximum duration allowed by the Access Rule)"},
+		&cli.BoolFlag{Name: "force", Usage: "Overwrite an existing saved request with the same name"},
+	},
+	Action: func(c *cli.Context) error {
+		ctx := c.Context
+
+		name := c.Args().First()
+		if name == "" {
+			return clierr.New("usage: cf request save [name] --rule [id]")
+		}
+
+		with, err := parseWith(c.StringSlice("with"))
+		if err != nil {
+			return err
+		}
+
+		cfg, err := config.Load()
+		if err != nil {
+			return err
+		}
+		current, err := cfg.Current()
+		if err != nil {
+			return err
+		}
+
+		if _, ok := current.SavedRequests[name]; ok && !c.Bool("force") {
+			return clierr.New(fmt.Sprintf("A saved request named '%s' already exists.", name), clierr.Info("Use --force to overwrite it."))
+		}
+
+		cf, err := client.FromConfig(ctx, cfg)
+		if err != nil {
+			return err
+		}
+
+		// validate the template against the Access Rule before saving it,
+		// so that argument labels are saved as option values.
+		body, err := buildRequest(ctx, cf, requestOpts{
+			RuleID:   c.String("rule"),
+			With:     with,
+			Duration: c.Duration("duration"),
+			Reason:   c.String("reason"),
+		})
+		if err != nil {
+			return err
+		}
+
+		saved := config.SavedRequest{
+			RuleID:          body.AccessRuleId,
+			DurationSeconds: int(c.Duration("duration").Seconds()),
+			Reason:          c.String("reason"),
+		}
+		if body.With != nil {
+			saved.With = (*body.With)[0].AdditionalProperties
+		}
+
+		if current.SavedRequests == nil {
+			current.SavedRequests = map[string]config.SavedRequest{}
+		}
+		current.SavedRequests[name] = saved
+		cfg.Contexts[cfg.CurrentContext] = *current
+
+		err = config.Save(cfg)
+		if err != nil {
+			return err
+		}
+
+		clio.Successf("Saved request '%s'. Run 'cf request again %s' to request it", name, name)
+
+		return nil
+	},
+}
+
+var AgainCommand = cli.Command{
+	Name:        "again",
+	Description: "Create an Access Request from a saved request template",
+	Usage:       "Create an Access Request from a saved request template",
+	ArgsUsage:   "<name>",
+	Flags: []cli.Flag{
+		output.Flag(),
+	},
+	Action: func(c *cli.Context) error {
+		ctx := c.Context
+
+		name := c.Args().First()
+		if name == "" {
+			return clierr.New("usage: cf request again [name]")
+		}
+
+		format, err := output.Format(c)
+		if err != nil {
+			return err
+		}
+
+		cfg, err := config.Load()
+		if err != nil {
+			return err
+		}
+		current, err := cfg.Current()
+		if err != nil {
+			return err
+		}
+
+		saved, ok := current.SavedRequests[name]
+		if !ok {
+			return clierr.New(fmt.Sprintf("Could not find a saved request named '%s'.", name), clierr.Infof("Saved requests: %s", joinOrNone(savedRequestNames(current.SavedRequests))))
+		}
+
+		cf, err := client.FromConfig(ctx, cfg)
+		if err != nil {
+			return err
+		}
+
+		body, err := buildRequest(ctx, cf, requestOpts{
+			RuleID:   saved.RuleID,
+			With:     saved.With,
+			Duration: time.Duration(saved.DurationSeconds) * time.Second,
+			Reason:   saved.Reason,
+		})
+		if err != nil {
+			return err
+		}
+
+		res, err := cf.UserCreateRequestWithResponse(ctx, body)
+		if err != nil {
+			return err
+		}
+
+		if format == output.JSON {
+			return output.PrintJSON(os.Stdout, res.JSON200.Requests)
+		}
+
+		return printRequests(os.Stdout, res.JSON200.Requests)
+	},
+}
+
+var SavedCommand = cli.Command{
+	Name:        "saved",
+	Description: "List saved Access Request templates",
+	Usage:       "List saved Access Request templates",
+	Action: func(c *cli.Context) error {
+		current, err := config.CurrentContext()
+		if err != nil {
+			return err
+		}
+
+		tbl := table.New(os.Stdout)
+		tbl.Columns("Name", "Rule", "Arguments", "Duration", "Reason")
+		for _, name := range savedRequestNames(current.SavedRequests) {
+			s := current.SavedRequests[name]
+
+			var keys []string
+			for k := range s.With {
+				keys = append(keys, k)
+			}
+			sort.Strings(keys)
+			var args []string
+			for _, k := range keys {
+				args = append(args, k+"="+strings.Join(s.With[k], ","))
+			}
+
+			duration := "max"
+			if s.DurationSeconds > 0 {
+				duration = formatDuration(s.DurationSeconds)
+			}
+			reason := "-"
+			if s.Reason != "" {
+				reason = s.Reason
+			}
+			tbl.Row(name, s.RuleID, joinOrNone(args), duration, reason)
+		}
+		return tbl.Flush()
+	},
+}
+
+// savedRequestNames returns the sorted names of the saved requests.
+func savedRequestNames(saved map[string]config.SavedRequest) []string {
+	var names []string
+	for k := range saved {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,216 @@
+package request
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/common-fate/clio"
+	"github.com/common-fate/clio/clierr"
+	"github.com/common-fate/common-fate/pkg/types"
+	"github.com/common-fate/glide-cli/pkg/client"
+	"github.com/common-fate/glide-cli/pkg/config"
+	"github.com/pkg/errors"
+	"github.com/sethvargo/go-retry"
+	"github.com/urfave/cli/v2"
+)
+
+// Exit codes returned by 'cf request watch' so that scripts
+// can tell why the command stopped waiting.
+const (
+	ExitDeclined    = 2
+	ExitTimeout     = 3
+	ExitGrantFailed = 4
+)
+
+// The states which 'cf request watch' can wait until.
+const (
+	untilApproved = "approved"
+	untilActive   = "active"
+	untilEnded    = "ended"
+)
+
+// Lifecycle states of a request, combining the request and grant statuses.
+// A request moves through PENDING -> APPROVED -> ACTIVE -> EXPIRED or REVOKED.
+const (
+	statePending   = "PENDING"
+	stateApproved  = "APPROVED"
+	stateDeclined  = "DECLINED"
+	stateCancelled = "CANCELLED"
+	stateActive    = "ACTIVE"
+	stateExpired   = "EXPIRED"
+	stateRevoked   = "REVOKED"
+	stateError     = "ERROR"
+)
+
+// requestState returns the lifecycle state of a request.
+func requestState(r types.RequestDetail) string {
+	if r.Status != types.RequestStatusAPPROVED {
+		return string(r.Status)
+	}
+	if r.Grant == nil || r.Grant.Status == types.GrantStatusPENDING {
+		return stateApproved
+	}
+	return string(r.Grant.Status)
+}
+
+// WatchError is returned when a request reaches a state
+// which means the state being waited for will never be reached.
+type WatchError struct {
+	Msg  string
+	Code int
+}
+
+func (e *WatchError) Error() string {
+	return e.Msg
+}
+
+type watchOpts struct {
+	ID      string
+	Until   string
+	Timeout time.Duration
+	// interval is the initial polling interval, which defaults to 2 seconds.
+	interval time.Duration
+}
+
+// waitForRequest polls a request with backoff until it reaches the state in opts.Until,
+// logging each state transition. A *WatchError is returned if the request is declined,
+// the grant fails, or the timeout is exceeded.
+func waitForRequest(ctx context.Context, cf *client.Client, opts watchOpts) (*types.RequestDetail, error) {
+	interval := opts.interval
+	if interval == 0 {
+		interval = time.Second * 2
+	}
+
+	var b retry.Backoff = retry.WithCappedDuration(time.Second*30, retry.NewExponential(interval))
+	if opts.Timeout > 0 {
+		b = retry.WithMaxDuration(opts.Timeout, b)
+	}
+
+	var (
+		last    string
+		request *types.RequestDetail
+	)
+
+	err := retry.Do(ctx, b, func(ctx context.Context) error {
+		// the response is nil if there is an error, so whether to retry
+		// is decided from the error: network errors and 5xx responses are retried.
+		res, err := cf.UserGetRequestWithResponse(ctx, opts.ID)
+		if client.IsRetryable(err) {
+			clio.Debugw("error polling request, retrying", "error", err)
+			return retry.RetryableError(err)
+		}
+		if err != nil {
+			return err
+		}
+
+		request = res.JSON200
+		state := requestState(*request)
+		if state != last {
+			if last == "" {
+				clio.Infof("Request %s is %s", opts.ID, state)
+			} else {
+				clio.Infof("Request %s: %s → %s", opts.ID, last, state)
+			}
+			last = state
+		}
+
+		done, err := watchDone(state, opts.Until)
+		if err != nil || done {
+			return err
+		}
+
+		// the below error will be shown to the user if the timeout is exceeded
+		return retry.RetryableError(&WatchError{
+			Msg:  fmt.Sprintf("timed out waiting for request %s to be %s (current state: %s)", opts.ID, opts.Until, state),
+			Code: ExitTimeout,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return request, nil
+}
+
+// watchDone returns true if the state satisfies the until condition.
+// It returns a *WatchError if the until condition can never be reached.
+func watchDone(state, until string) (bool, error) {
+	switch state {
+	case stateDeclined, stateCancelled:
+		return false, &WatchError{Msg: "request was " + strings.ToLower(state), Code: ExitDeclined}
+	case stateError:
+		return false, &WatchError{Msg: "the grant for the request failed", Code: ExitGrantFailed}
+	}
+
+	switch until {
+	case untilApproved:
+		return state != statePending, nil
+	case untilActive:
+		if state == stateExpired || state == stateRevoked {
+			return false, &WatchError{Msg: "the grant was " + strings.ToLower(state) + " before it could be used", Code: ExitGrantFailed}
+		}
+		return state == stateActive, nil
+	case untilEnded:
+		return state == stateExpired || state == stateRevoked, nil
+	}
+	return false, fmt.Errorf("invalid --until value '%s': supported values are %s, %s and %s", until, untilApproved, untilActive, untilEnded)
+}
+
+// exitOnWatchError logs the error and converts a *WatchError into a
+// cli.ExitCoder so the command exits with the corresponding exit code.
+func exitOnWatchError(err error) error {
+	var we *WatchError
+	if errors.As(err, &we) {
+		clio.Error(we.Msg)
+		return cli.Exit("", we.Code)
+	}
+	return err
+}
+
+var WatchCommand = cli.Command{
+	Name:        "watch",
+	Description: "Wait for an Access Request to be approved and for its grant to become active. Exits with code 2 if the request is declined or cancelled, 3 on timeout, and 4 if the grant fails or ends before becoming active.",
+	Usage:       "Wait for an Access Request to be approved and become active",
+	ArgsUsage:   "<id>",
+	Flags: []cli.Flag{
+		&cli.StringFlag{Name: "until", Value: untilActive, Usage: "The state to wait for (one of approved, active, ended)"},
+		&cli.DurationFlag{Name: "timeout", Value: time.Hour, Usage: "How long to wait before giving up (0 waits forever)"},
+	},
+	Action: func(c *cli.Context) error {
+		ctx := c.Context
+
+		id := c.Args().First()
+		if id == "" {
+			return clierr.New("usage: cf request watch [id]")
+		}
+
+		until := c.String("until")
+		if _, err := watchDone(statePending, until); err != nil {
+			return err
+		}
+
+		cfg, err := config.Load()
+		if err != nil {
+			return err
+		}
+
+		cf, err := client.FromConfig(ctx, cfg)
+		if err != nil {
+			return err
+		}
+
+		_, err = waitForRequest(ctx, cf, watchOpts{
+			ID:      id,
+			Until:   until,
+			Timeout: c.Duration("timeout"),
+		})
+		if err != nil {
+			return exitOnWatchError(err)
+		}
+
+		clio.Successf("Request %s is %s", id, until)
+
+		return nil
+	},
+}
@@ -0,0 +1,106 @@
+package request
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/common-fate/common-fate/pkg/types"
+	"github.com/common-fate/glide-cli/pkg/client"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWatchDone(t *testing.T) {
+	type testcase struct {
+		name     string
+		state    string
+		until    string
+		done     bool
+		exitCode int
+	}
+
+	testcases := []testcase{
+		{name: "pending waiting for active", state: statePending, until: untilActive},
+		{name: "approved waiting for active", state: stateApproved, until: untilActive},
+		{name: "active waiting for active", state: stateActive, until: untilActive, done: true},
+		{name: "approved waiting for approved", state: stateApproved, until: untilApproved, done: true},
+		{name: "active waiting for approved", state: stateActive, until: untilApproved, done: true},
+		{name: "active waiting for ended", state: stateActive, until: untilEnded},
+		{name: "expired waiting for ended", state: stateExpired, until: untilEnded, done: true},
+		{name: "declined", state: stateDeclined, until: untilActive, exitCode: ExitDeclined},
+		{name: "cancelled", state: stateCancelled, until: untilApproved, exitCode: ExitDeclined},
+		{name: "grant error", state: stateError, until: untilActive, exitCode: ExitGrantFailed},
+		{name: "revoked waiting for active", state: stateRevoked, until: untilActive, exitCode: ExitGrantFailed},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			done, err := watchDone(tc.state, tc.until)
+			assert.Equal(t, tc.done, done)
+
+			if tc.exitCode == 0 {
+				assert.NoError(t, err)
+				return
+			}
+			var we *WatchError
+			assert.ErrorAs(t, err, &we)
+			assert.Equal(t, tc.exitCode, we.Code)
+		})
+	}
+}
+
+func TestWaitForRequestRetriesServerErrors(t *testing.T) {
+	// the API client loads the CLI config when making requests.
+	t.Setenv("HOME", t.TempDir())
+
+	type testcase struct {
+		name      string
+		status    int
+		wantCalls int
+		wantErr   bool
+	}
+
+	testcases := []testcase{
+		{name: "5xx is retried", status: http.StatusBadGateway, wantCalls: 2},
+		{name: "4xx is not retried", status: http.StatusNotFound, wantCalls: 1, wantErr: true},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			var calls int
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				calls++
+				if calls == 1 {
+					w.WriteHeader(tc.status)
+					return
+				}
+				w.Header().Set("Content-Type", "application/json")
+				_, _ = w.Write([]byte(`{"id":"req_123","status":"APPROVED"}`))
+			}))
+			defer srv.Close()
+
+			cf, err := types.NewClientWithResponses(srv.URL, types.WithHTTPClient(&client.ErrorHandlingClient{Client: srv.Client()}))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			req, err := waitForRequest(context.Background(), cf, watchOpts{
+				ID:       "req_123",
+				Until:    untilApproved,
+				Timeout:  time.Second * 5,
+				interval: time.Millisecond,
+			})
+			assert.Equal(t, tc.wantCalls, calls)
+			if tc.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			assert.Equal(t, types.RequestStatusAPPROVED, req.Status)
+		})
+	}
+}
@@ -0,0 +1,155 @@
+package rules
+
+import (
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/common-fate/clio"
+	"github.com/common-fate/clio/clierr"
+	"github.com/common-fate/common-fate/pkg/types"
+	"github.com/common-fate/glide-cli/pkg/client"
+	"github.com/common-fate/glide-cli/pkg/config"
+	"github.com/common-fate/glide-cli/pkg/output"
+	"github.com/common-fate/glide-cli/pkg/table"
+	"github.com/urfave/cli/v2"
+)
+
+// ruleDetail is the structured output of 'cf rules get'.
+type ruleDetail struct {
+	types.RequestAccessRule
+	// Approvers are the users who can approve requests for the rule.
+	// If there are no approvers, requests are automatically approved.
+	Approvers []string `json:"approvers"`
+	// Groups and ApprovalGroups are only available to administrators.
+	Groups         []string `json:"groups,omitempty"`
+	ApprovalGroups []string `json:"approvalGroups,omitempty"`
+}
+
+var get = cli.Command{
+	Name:      "get",
+	Usage:     "Show the details of an Access Rule",
+	ArgsUsage: "<id>",
+	Flags: []cli.Flag{
+		output.Flag(),
+	},
+	Action: func(c *cli.Context) error {
+		ctx := c.Context
+
+		id := c.Args().First()
+		if id == "" {
+			return clierr.New("usage: cf rules get [id]")
+		}
+
+		format, err := output.Format(c)
+		if err != nil {
+			return err
+		}
+
+		cfg, err := config.Load()
+		if err != nil {
+			return err
+		}
+
+		cf, err := client.FromConfig(ctx, cfg)
+		if err != nil {
+			return err
+		}
+
+		rule, err := cf.UserGetAccessRuleWithResponse(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		approvers, err := cf.UserGetAccessRuleApproversWithResponse(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		rd := ruleDetail{
+			RequestAccessRule: *rule.JSON200,
+			Approvers:         approvers.JSON200.Users,
+		}
+
+		// groups are only exposed through the admin API, so only show them
+		// if the user is an administrator.
+		adminRule, err := cf.AdminGetAccessRuleWithResponse(ctx, id)
+		if err != nil {
+			clio.Debugw("could not load admin Access Rule details, skipping groups", "error", err)
+		} else {
+			rd.Groups = adminRule.JSON200.Groups
+			if adminRule.JSON200.Approval.Groups != nil {
+				rd.ApprovalGroups = *adminRule.JSON200.Approval.Groups
+			}
+		}
+
+		if format == output.JSON {
+			return output.PrintJSON(os.Stdout, rd)
+		}
+
+		w := table.New(os.Stdout)
+		w.Columns("ID", "NAME", "PROVIDER", "MAX DURATION", "CAN REQUEST")
+		w.Row(rd.ID, rd.Name, rd.Target.Provider.Type, formatDuration(rd.TimeConstraints.MaxDurationSeconds), strconv.FormatBool(rd.CanRequest))
+		err = w.Flush()
+		if err != nil {
+			return err
+		}
+
+		if rd.Description != "" {
+			clio.NewLine()
+			clio.Log(rd.Description)
+		}
+
+		clio.NewLine()
+		clio.Log("Arguments")
+		w.Columns("ARGUMENT", "TITLE", "LABEL", "VALUE")
+		args := rd.Target.Arguments.AdditionalProperties
+		var keys []string
+		for k := range args {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			for _, o := range args[k].Options {
+				w.Row(k, args[k].Title, o.Label, o.Value)
+			}
+		}
+		err = w.Flush()
+		if err != nil {
+			return err
+		}
+
+		clio.NewLine()
+		clio.Log("Approval")
+		w.Columns("APPROVAL REQUIRED", "APPROVERS", "APPROVAL GROUPS")
+		w.Row(strconv.FormatBool(len(rd.Approvers) > 0), joinOrNone(rd.Approvers), joinOrNone(rd.ApprovalGroups))
+		err = w.Flush()
+		if err != nil {
+			return err
+		}
+
+		if rd.Groups != nil {
+			clio.NewLine()
+			clio.Log("Groups")
+			w.Columns("GROUP")
+			for _, g := range rd.Groups {
+				w.Row(g)
+			}
+		}
+
+		return w.Flush()
+	},
+}
+
+func formatDuration(seconds int) string {
+	return (time.Duration(seconds) * time.Second).String()
+}
+
+func joinOrNone(s []string) string {
+	if len(s) == 0 {
+		return "-"
+	}
+	return strings.Join(s, ", ")
+}
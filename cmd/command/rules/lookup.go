@@ -1,9 +1,12 @@
 package rules
 
 import (
+	"fmt"
 	"os"
+	"sort"
 	"strings"
 
+	"github.com/common-fate/clio/clierr"
 	"github.com/common-fate/common-fate/pkg/types"
 	"github.com/common-fate/glide-cli/pkg/client"
 	"github.com/common-fate/glide-cli/pkg/config"
@@ -11,15 +14,62 @@ import (
 	"github.com/urfave/cli/v2"
 )
 
+// lookupParams maps the keys accepted by the --value flag
+// onto the Access Rule lookup API parameters.
+var lookupParams = map[string]func(p *types.UserLookupAccessRuleParams, val string){
+	"accountId": func(p *types.UserLookupAccessRuleParams, val string) {
+		p.AccountId = &val
+	},
+	"permissionSetArn.label": func(p *types.UserLookupAccessRuleParams, val string) {
+		p.PermissionSetArnLabel = &val
+	},
+	// 'account' and 'role.label' are supported for backwards compatibility.
+	"account": func(p *types.UserLookupAccessRuleParams, val string) {
+		p.AccountId = &val
+	},
+	"role.label": func(p *types.UserLookupAccessRuleParams, val string) {
+		p.PermissionSetArnLabel = &val
+	},
+}
+
+// lookupKeys returns the sorted keys accepted by the --value flag.
+func lookupKeys() []string {
+	var keys []string
+	for k := range lookupParams {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
 var lookup = cli.Command{
 	Name:  "lookup",
 	Usage: "Lookup Access Rules",
 	Flags: []cli.Flag{
-		&cli.StringSliceFlag{Name: "value", Aliases: []string{"v"}},
+		&cli.StringFlag{Name: "type", Value: "commonfate/aws-sso", Usage: "The Access Provider type to look up rules for"},
+		&cli.StringSliceFlag{Name: "value", Aliases: []string{"v"}, Usage: "An argument value to match in key=value format (for example, 'accountId=123456789012')"},
 	},
 	Action: func(c *cli.Context) error {
 		ctx := c.Context
 
+		provider := types.UserLookupAccessRuleParamsType(c.String("type"))
+		params := types.UserLookupAccessRuleParams{
+			Type: &provider,
+		}
+
+		for _, kv := range c.StringSlice("value") {
+			splits := strings.SplitN(kv, "=", 2)
+			if len(splits) != 2 {
+				return fmt.Errorf("invalid value argument (expected format is --value key=value): %s", kv)
+			}
+
+			setParam, ok := lookupParams[splits[0]]
+			if !ok {
+				return clierr.New(fmt.Sprintf("Unknown lookup key '%s'.", splits[0]), clierr.Infof("Supported keys: %s", strings.Join(lookupKeys(), ", ")))
+			}
+			setParam(&params, splits[1])
+		}
+
 		cfg, err := config.Load()
 		if err != nil {
 			return err
@@ -30,38 +80,24 @@ var lookup = cli.Command{
 			return err
 		}
 
-		provider := types.UserLookupAccessRuleParamsType("commonfate/aws-sso")
-
-		var roleLabel string
-		var account string
-
-		valuesKV := c.StringSlice("value")
-		for _, kv := range valuesKV {
-			splits := strings.SplitN(kv, "=", 2)
-			if splits[0] == "role.label" {
-				roleLabel = splits[1]
-			}
-			if splits[0] == "account" {
-				account = splits[1]
-			}
-		}
-
-		res, err := cf.UserLookupAccessRuleWithResponse(ctx, &types.UserLookupAccessRuleParams{
-			Type:                  &provider,
-			PermissionSetArnLabel: &roleLabel,
-			AccountId:             &account,
-		})
+		res, err := cf.UserLookupAccessRuleWithResponse(ctx, &params)
 		if err != nil {
 			return err
 		}
 
 		w := table.New(os.Stdout)
-		w.Columns("ID", "NAME")
+		w.Columns("ID", "NAME", "VALUES")
 
 		rules := *res.JSON200
 
 		for _, p := range rules {
-			w.Row(p.AccessRule.ID, p.AccessRule.Name)
+			var values []string
+			if p.SelectableWithOptionValues != nil {
+				for _, kv := range *p.SelectableWithOptionValues {
+					values = append(values, kv.Key+"="+kv.Value)
+				}
+			}
+			w.Row(p.AccessRule.ID, p.AccessRule.Name, strings.Join(values, ", "))
 		}
 
 		w.Flush()
@@ -7,6 +7,7 @@ var Command = cli.Command{
 	Usage: "View and manage Access Rules",
 	Subcommands: []*cli.Command{
 		&list,
+		&get,
 		&lookup,
 	},
 }
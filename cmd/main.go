@@ -5,6 +5,7 @@ import (
 
 	"github.com/common-fate/clio"
 	"github.com/common-fate/glide-cli/cmd/command"
+	"github.com/common-fate/glide-cli/cmd/command/aws"
 	"github.com/common-fate/glide-cli/cmd/command/bootstrap"
 	"github.com/common-fate/glide-cli/cmd/command/config"
 	"github.com/common-fate/glide-cli/cmd/command/handler"
@@ -12,6 +13,8 @@ import (
 
 	"github.com/common-fate/clio/clierr"
 	"github.com/common-fate/glide-cli/cmd/command/provider"
+	"github.com/common-fate/glide-cli/cmd/command/registry"
+	"github.com/common-fate/glide-cli/cmd/command/request"
 	"github.com/common-fate/glide-cli/cmd/command/rules"
 	"github.com/common-fate/glide-cli/cmd/command/targetgroup"
 	mw "github.com/common-fate/glide-cli/cmd/middleware"
@@ -40,9 +43,16 @@ func main() {
 		Commands: []*cli.Command{
 			&command.Login,
 			&command.Logout,
+			&command.Apply,
+			&command.Export,
 			&config.Command,
 			&rules.Command,
+			&request.Command,
+			&request.ReviewCommand,
+			&request.ExecCommand,
+			&aws.Command,
 			&provider.Command,
+			&registry.Command,
 			&targetgroup.Command,
 			&handler.Command,
 			mw.WithBeforeFuncs(&bootstrap.Command, mw.RequireAWSCredentials()),
@@ -19,6 +19,28 @@ import (
 	"golang.org/x/oauth2"
 )
 
+// APIError is returned by ErrorHandlingClient when the API responds with an error status code.
+// It prints like the underlying CLI error, and includes the status code so that callers
+// can tell temporary server errors apart from errors which won't succeed if retried.
+type APIError struct {
+	*clierr.Err
+	StatusCode int
+}
+
+// IsRetryable returns true if an error returned by the Common Fate API client is
+// temporary: either a network error, or a 5xx response from the API.
+func IsRetryable(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) {
+		return false
+	}
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.StatusCode >= 500
+	}
+	var ne *url.Error
+	return errors.As(err, &ne)
+}
+
 // ErrorHandlingClient checks the response status code
 // and creates an error if the API returns greater than 300.
 type ErrorHandlingClient struct {
@@ -92,7 +114,7 @@ func (rd *ErrorHandlingClient) Do(req *http.Request) (*http.Response, error) {
 
 	}
 
-	return res, e
+	return res, &APIError{Err: e, StatusCode: res.StatusCode}
 }
 
 type ClientOpts struct {
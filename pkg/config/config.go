@@ -18,6 +18,20 @@ type Context struct {
 	DashboardURL   string `toml:"dashboard_url" json:"dashboard_url"`
 	APIURL         string `toml:"api_url,omitempty" json:"api_url,omitempty"`
 	RegistryAPIURL string `toml:"registry_api_url,omitempty" json:"registry_api_url,omitempty"`
+	// SavedRequests are named Access Request templates,
+	// which can be requested again with 'cf request again [name]'.
+	SavedRequests map[string]SavedRequest `toml:"saved_request,omitempty" json:"saved_request,omitempty"`
+}
+
+// SavedRequest is a template for an Access Request.
+type SavedRequest struct {
+	RuleID string `toml:"rule_id" json:"rule_id"`
+	// With maps argument keys to option values.
+	With map[string][]string `toml:"with,omitempty" json:"with,omitempty"`
+	// DurationSeconds is the requested duration. If zero, the maximum
+	// duration allowed by the Access Rule is requested.
+	DurationSeconds int    `toml:"duration_seconds,omitempty" json:"duration_seconds,omitempty"`
+	Reason          string `toml:"reason,omitempty" json:"reason,omitempty"`
 }
 
 // Keys are all of the allowed keys in the Context section.
@@ -0,0 +1,155 @@
+// Package deployment records the progress of provider deployments,
+// so that 'cf provider deploy' can be resumed if it is interrupted.
+package deployment
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Step is a step of a provider deployment.
+type Step string
+
+const (
+	// AssetsCopied is completed when the provider assets
+	// are copied to the bootstrap bucket.
+	AssetsCopied Step = "assets-copied"
+	// StackDeployed is completed when the Handler CloudFormation stack is deployed.
+	StackDeployed Step = "stack-deployed"
+	// Registered is completed when the Target Group and Handler are
+	// registered with Common Fate and linked together.
+	Registered Step = "registered"
+)
+
+// ErrNotFound is returned by Load if there is no record for a Handler.
+var ErrNotFound = errors.New("deployment record not found")
+
+// Parameter is a CloudFormation parameter for the Handler stack.
+// Secrets are stored as references to SSM parameters, so
+// records never contain secret values.
+type Parameter struct {
+	Key              string `json:"key"`
+	Value            string `json:"value,omitempty"`
+	UsePreviousValue bool   `json:"usePreviousValue,omitempty"`
+}
+
+// Record is the progress of a provider deployment.
+type Record struct {
+	HandlerID     string      `json:"handlerId"`
+	Provider      string      `json:"provider"`
+	Kind          string      `json:"kind"`
+	TargetGroupID string      `json:"targetGroupId"`
+	AWSAccount    string      `json:"awsAccount"`
+	AWSRegion     string      `json:"awsRegion"`
+	Parameters    []Parameter `json:"parameters"`
+	// StackID is set once the stack is deployed.
+	StackID string `json:"stackId,omitempty"`
+	// NewStack is true if the stack didn't exist when the deployment started.
+	NewStack bool `json:"newStack,omitempty"`
+	// CreatesTargetGroup, CreatesHandler and CreatesLink are true if the
+	// deployment creates the resource in Common Fate rather than reusing it.
+	CreatesTargetGroup bool      `json:"createsTargetGroup,omitempty"`
+	CreatesHandler     bool      `json:"createsHandler,omitempty"`
+	CreatesLink        bool      `json:"createsLink,omitempty"`
+	CompletedSteps     []Step    `json:"completedSteps"`
+	UpdatedAt          time.Time `json:"updatedAt"`
+
+	// path is the file the record is saved to.
+	path string
+}
+
+// DefaultDir returns the folder that deployment records are stored in,
+// which is ~/.commonfate/deployments.
+func DefaultDir() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".commonfate", "deployments"), nil
+}
+
+// New returns an empty record for a Handler, which is saved in dir.
+func New(dir string, handlerID string) *Record {
+	return &Record{
+		HandlerID:      handlerID,
+		CompletedSteps: []Step{},
+		path:           recordPath(dir, handlerID),
+	}
+}
+
+// Load reads the record for a Handler from dir.
+// If the record doesn't exist, ErrNotFound is returned.
+func Load(dir string, handlerID string) (*Record, error) {
+	p := recordPath(dir, handlerID)
+	b, err := os.ReadFile(p)
+	if os.IsNotExist(err) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var r Record
+	err = json.Unmarshal(b, &r)
+	if err != nil {
+		return nil, errors.Wrapf(err, "reading deployment record %s", p)
+	}
+	r.path = p
+	return &r, nil
+}
+
+// Path returns the file that the record is saved to.
+func (r *Record) Path() string {
+	return r.path
+}
+
+// Completed returns true if the step has been completed.
+func (r *Record) Completed(step Step) bool {
+	for _, s := range r.CompletedSteps {
+		if s == step {
+			return true
+		}
+	}
+	return false
+}
+
+// Complete marks a step as completed and saves the record.
+func (r *Record) Complete(step Step) error {
+	if !r.Completed(step) {
+		r.CompletedSteps = append(r.CompletedSteps, step)
+	}
+	return r.Save()
+}
+
+// Save writes the record to disk.
+func (r *Record) Save() error {
+	r.UpdatedAt = time.Now()
+
+	b, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	err = os.MkdirAll(filepath.Dir(r.path), 0700)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(r.path, b, 0600)
+}
+
+// Remove deletes the record once the deployment has completed.
+func (r *Record) Remove() error {
+	err := os.Remove(r.path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
+
+func recordPath(dir string, handlerID string) string {
+	return filepath.Join(dir, handlerID+".json")
+}
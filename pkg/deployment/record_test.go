@@ -0,0 +1,43 @@
+package deployment
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecord(t *testing.T) {
+	dir := t.TempDir()
+
+	_, err := Load(dir, "cf-handler-common-fate-aws")
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	r := New(dir, "cf-handler-common-fate-aws")
+	r.Provider = "common-fate/aws@v0.4.0"
+	r.Parameters = []Parameter{{Key: "ApiTokenSecret", Value: "awsssm:///common-fate/provider/common-fate/aws/cf-handler-common-fate-aws/apiToken"}}
+	err = r.Complete(AssetsCopied)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// completing a step twice only records it once
+	err = r.Complete(AssetsCopied)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := Load(dir, "cf-handler-common-fate-aws")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, []Step{AssetsCopied}, got.CompletedSteps)
+	assert.True(t, got.Completed(AssetsCopied))
+	assert.False(t, got.Completed(StackDeployed))
+	assert.Equal(t, r.Parameters, got.Parameters)
+
+	err = got.Remove()
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = Load(dir, "cf-handler-common-fate-aws")
+	assert.ErrorIs(t, err, ErrNotFound)
+}
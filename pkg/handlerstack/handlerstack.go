@@ -0,0 +1,53 @@
+// Package handlerstack contains helpers for the CloudFormation
+// stacks which are deployed for Handlers by 'cf provider deploy'.
+package handlerstack
+
+import (
+	"path"
+	"strings"
+
+	"github.com/common-fate/provider-registry-sdk-go/pkg/bootstrapper"
+	"github.com/common-fate/provider-registry-sdk-go/pkg/providerregistrysdk"
+)
+
+// Parameters which are set on every Handler stack, in addition
+// to the config parameters in the provider schema.
+const (
+	ParamAssetPath              = "AssetPath"
+	ParamBootstrapBucketName    = "BootstrapBucketName"
+	ParamCommonFateAWSAccountID = "CommonFateAWSAccountID"
+	ParamHandlerID              = "HandlerID"
+)
+
+// AssetPath returns the path of the handler.zip asset for
+// a provider in the bootstrap bucket.
+func AssetPath(p providerregistrysdk.Provider) string {
+	return path.Join(bootstrapper.AssetPath(p), "handler.zip")
+}
+
+// ProviderFromAssetPath parses the provider from the AssetPath parameter of a Handler stack,
+// which has the format '<registry path>/<publisher>/<name>/<version>/handler.zip'.
+func ProviderFromAssetPath(assetPath string) (providerregistrysdk.Provider, bool) {
+	parts := strings.Split(assetPath, "/")
+	if len(parts) < 4 || parts[len(parts)-1] != "handler.zip" {
+		return providerregistrysdk.Provider{}, false
+	}
+	n := len(parts)
+	return providerregistrysdk.Provider{
+		Publisher: parts[n-4],
+		Name:      parts[n-3],
+		Version:   parts[n-2],
+	}, true
+}
+
+// SecretParameters returns the parameters of a Handler stack which contain references
+// to secret config values. Secret config parameters have the suffix 'Secret'.
+func SecretParameters(params map[string]string) map[string]string {
+	secrets := map[string]string{}
+	for k, v := range params {
+		if strings.HasSuffix(k, "Secret") {
+			secrets[k] = v
+		}
+	}
+	return secrets
+}
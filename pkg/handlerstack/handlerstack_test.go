@@ -0,0 +1,50 @@
+package handlerstack
+
+import (
+	"testing"
+
+	"github.com/common-fate/provider-registry-sdk-go/pkg/providerregistrysdk"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProviderFromAssetPath(t *testing.T) {
+	type testcase struct {
+		name      string
+		assetPath string
+		want      providerregistrysdk.Provider
+		wantOK    bool
+	}
+
+	testcases := []testcase{
+		{
+			name:      "registry asset path",
+			assetPath: "registry.commonfate.io/v1alpha1/providers/common-fate/aws/v0.4.0/handler.zip",
+			want:      providerregistrysdk.Provider{Publisher: "common-fate", Name: "aws", Version: "v0.4.0"},
+			wantOK:    true,
+		},
+		{
+			name:      "asset path is generated",
+			assetPath: AssetPath(providerregistrysdk.Provider{Publisher: "common-fate", Name: "aws", Version: "v0.4.0"}),
+			want:      providerregistrysdk.Provider{Publisher: "common-fate", Name: "aws", Version: "v0.4.0"},
+			wantOK:    true,
+		},
+		{
+			name:      "not a handler asset",
+			assetPath: "common-fate/aws/v0.4.0/cloudformation.json",
+			wantOK:    false,
+		},
+		{
+			name:      "too short",
+			assetPath: "aws/handler.zip",
+			wantOK:    false,
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, ok := ProviderFromAssetPath(tc.assetPath)
+			assert.Equal(t, tc.wantOK, ok)
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
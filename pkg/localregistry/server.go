@@ -0,0 +1,229 @@
+// Package localregistry serves Providers from a local directory
+// using the same API as the Common Fate Provider Registry.
+//
+// It's useful for testing provider flows offline and for hosting
+// private providers which aren't published to the public registry.
+package localregistry
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/common-fate/clio"
+	"github.com/common-fate/provider-registry-sdk-go/pkg/providerregistrysdk"
+	"github.com/go-chi/chi/v5"
+	"github.com/pkg/errors"
+)
+
+// AssetsPrefix is the path that provider assets (handler.zip and cloudformation.json)
+// are served under. The bootstrapper downloads assets from
+// COMMON_FATE_PROVIDER_REGISTRY_S3_URL, so this should be set to '<server URL>/assets'.
+const AssetsPrefix = "/assets"
+
+// Server serves providers from a directory with the layout:
+//
+//	<dir>/<publisher>/<name>/<version>/provider.json
+//	<dir>/<publisher>/<name>/<version>/handler.zip
+//	<dir>/<publisher>/<name>/<version>/cloudformation.json
+//	<dir>/<publisher>/<name>/<version>/README.md (optional)
+//
+// provider.json contains a ProviderDetail object. If the publisher, name or
+// version fields are empty they are populated from the directory path.
+//
+// Server implements providerregistrysdk.ServerInterface.
+type Server struct {
+	Dir string
+}
+
+// New creates a new local registry server which serves providers from dir.
+func New(dir string) *Server {
+	return &Server{Dir: dir}
+}
+
+// Handler returns the HTTP handler for the registry API and provider assets.
+func (s *Server) Handler() http.Handler {
+	r := chi.NewRouter()
+	r.Get(AssetsPrefix+"/{publisher}/{name}/{version}/{file}", s.getAsset)
+	return providerregistrysdk.HandlerFromMux(s, r)
+}
+
+func (s *Server) Healthcheck(w http.ResponseWriter, r *http.Request) {
+	returnJSON(w, http.StatusOK, providerregistrysdk.HealthResponse{Healthy: true})
+}
+
+func (s *Server) UserGetMe(w http.ResponseWriter, r *http.Request) {
+	returnError(w, http.StatusNotImplemented, "the local registry does not support authentication")
+}
+
+func (s *Server) ListAllProviders(w http.ResponseWriter, r *http.Request, params providerregistrysdk.ListAllProvidersParams) {
+	providers, err := s.list("*", "*")
+	if err != nil {
+		returnError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	returnJSON(w, http.StatusOK, providerregistrysdk.ListProvidersResponse{Providers: providers})
+}
+
+func (s *Server) ListProviderVersions(w http.ResponseWriter, r *http.Request, publisher string, name string) {
+	if err := validateSegments(publisher, name); err != nil {
+		returnError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	providers, err := s.list(publisher, name)
+	if err != nil {
+		returnError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if len(providers) == 0 {
+		returnError(w, http.StatusNotFound, "provider not found")
+		return
+	}
+	returnJSON(w, http.StatusOK, providerregistrysdk.ListProvidersResponse{Providers: providers})
+}
+
+func (s *Server) GetProvider(w http.ResponseWriter, r *http.Request, publisher string, name string, version string) {
+	if err := validateSegments(publisher, name, version); err != nil {
+		returnError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	p, err := s.get(publisher, name, version)
+	if os.IsNotExist(err) {
+		returnError(w, http.StatusNotFound, "provider not found")
+		return
+	}
+	if err != nil {
+		returnError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	returnJSON(w, http.StatusOK, p)
+}
+
+func (s *Server) GetProviderReadme(w http.ResponseWriter, r *http.Request, publisher string, name string, version string) {
+	if err := validateSegments(publisher, name, version); err != nil {
+		returnError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	readme, err := os.ReadFile(filepath.Join(s.Dir, publisher, name, version, "README.md"))
+	if os.IsNotExist(err) {
+		returnError(w, http.StatusNotFound, "readme not found")
+		return
+	}
+	if err != nil {
+		returnError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	returnJSON(w, http.StatusOK, providerregistrysdk.ProviderReadmeResponse{Readme: string(readme)})
+}
+
+func (s *Server) UserPublishProvider(w http.ResponseWriter, r *http.Request) {
+	returnError(w, http.StatusNotImplemented, "the local registry does not support publishing: copy the provider files into the registry directory instead")
+}
+
+func (s *Server) UserCompletePublishProvider(w http.ResponseWriter, r *http.Request) {
+	returnError(w, http.StatusNotImplemented, "the local registry does not support publishing: copy the provider files into the registry directory instead")
+}
+
+func (s *Server) UserCreatePublisher(w http.ResponseWriter, r *http.Request) {
+	returnError(w, http.StatusNotImplemented, "the local registry does not support creating publishers")
+}
+
+// getAsset serves the handler.zip and cloudformation.json files for a provider.
+func (s *Server) getAsset(w http.ResponseWriter, r *http.Request) {
+	file := chi.URLParam(r, "file")
+	if file != "handler.zip" && file != "cloudformation.json" {
+		http.NotFound(w, r)
+		return
+	}
+	publisher, name, version := chi.URLParam(r, "publisher"), chi.URLParam(r, "name"), chi.URLParam(r, "version")
+	if err := validateSegments(publisher, name, version); err != nil {
+		returnError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	fp := filepath.Join(s.Dir, publisher, name, version, file)
+	http.ServeFile(w, r, fp)
+}
+
+// list returns the providers matching the publisher and name glob patterns,
+// sorted by publisher, name and version.
+func (s *Server) list(publisher, name string) ([]providerregistrysdk.ProviderDetail, error) {
+	matches, err := filepath.Glob(filepath.Join(s.Dir, publisher, name, "*", "provider.json"))
+	if err != nil {
+		return nil, err
+	}
+
+	providers := []providerregistrysdk.ProviderDetail{}
+	for _, m := range matches {
+		versionDir := filepath.Dir(m)
+		nameDir := filepath.Dir(versionDir)
+		publisherDir := filepath.Dir(nameDir)
+
+		p, err := s.get(filepath.Base(publisherDir), filepath.Base(nameDir), filepath.Base(versionDir))
+		if err != nil {
+			return nil, err
+		}
+		providers = append(providers, *p)
+	}
+
+	sort.Slice(providers, func(i, j int) bool {
+		return providers[i].String() < providers[j].String()
+	})
+
+	return providers, nil
+}
+
+// get reads the provider.json file for a particular provider version.
+func (s *Server) get(publisher, name, version string) (*providerregistrysdk.ProviderDetail, error) {
+	fp := filepath.Join(s.Dir, publisher, name, version, "provider.json")
+	b, err := os.ReadFile(fp)
+	if err != nil {
+		return nil, err
+	}
+
+	var p providerregistrysdk.ProviderDetail
+	err = json.Unmarshal(b, &p)
+	if err != nil {
+		return nil, errors.Wrapf(err, "parsing %s", fp)
+	}
+
+	if p.Publisher == "" {
+		p.Publisher = publisher
+	}
+	if p.Name == "" {
+		p.Name = name
+	}
+	if p.Version == "" {
+		p.Version = version
+	}
+
+	return &p, nil
+}
+
+// validateSegments checks that the publisher, name and version from a request URL
+// are single path segments, so that they can't be used to read files outside of
+// the registry directory, or be interpreted as glob patterns.
+func validateSegments(segments ...string) error {
+	for _, seg := range segments {
+		if seg == "" || seg == "." || strings.Contains(seg, "..") || strings.ContainsAny(seg, `/\*?[`) {
+			return fmt.Errorf("invalid path segment '%s'", seg)
+		}
+	}
+	return nil
+}
+
+func returnJSON(w http.ResponseWriter, code int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	err := json.NewEncoder(w).Encode(body)
+	if err != nil {
+		clio.Errorf("error writing response: %s", err.Error())
+	}
+}
+
+func returnError(w http.ResponseWriter, code int, msg string) {
+	returnJSON(w, code, providerregistrysdk.ErrorResponse{Error: msg})
+}
@@ -0,0 +1,115 @@
+package localregistry
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/common-fate/provider-registry-sdk-go/pkg/providerregistrysdk"
+	"github.com/common-fate/provider-registry-sdk-go/pkg/registryclient"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeFile(t *testing.T, path string, contents string) {
+	err := os.MkdirAll(filepath.Dir(path), os.ModePerm)
+	require.NoError(t, err)
+	err = os.WriteFile(path, []byte(contents), 0600)
+	require.NoError(t, err)
+}
+
+func TestServer(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "acme", "test", "v0.1.0", "provider.json"), `{"schema":{"targets":{"Account":{"type":"object","properties":{}}}}}`)
+	writeFile(t, filepath.Join(dir, "acme", "test", "v0.2.0", "provider.json"), `{}`)
+	writeFile(t, filepath.Join(dir, "acme", "test", "v0.2.0", "handler.zip"), "zip")
+	writeFile(t, filepath.Join(dir, "acme", "test", "v0.2.0", "README.md"), "# Test")
+
+	ts := httptest.NewServer(New(dir).Handler())
+	defer ts.Close()
+
+	ctx := context.Background()
+	registry, err := registryclient.NewWithURL(ctx, ts.URL)
+	require.NoError(t, err)
+
+	t.Run("list all providers", func(t *testing.T) {
+		res, err := registry.ListAllProvidersWithResponse(ctx, &providerregistrysdk.ListAllProvidersParams{})
+		require.NoError(t, err)
+		require.Len(t, res.JSON200.Providers, 2)
+		assert.Equal(t, "acme/test@v0.1.0", res.JSON200.Providers[0].String())
+		assert.Equal(t, "acme/test@v0.2.0", res.JSON200.Providers[1].String())
+	})
+
+	t.Run("get provider", func(t *testing.T) {
+		res, err := registry.GetProviderWithResponse(ctx, "acme", "test", "v0.1.0")
+		require.NoError(t, err)
+		assert.Contains(t, *res.JSON200.Schema.Targets, "Account")
+	})
+
+	t.Run("get provider not found", func(t *testing.T) {
+		_, err := registry.GetProviderWithResponse(ctx, "acme", "test", "v9.9.9")
+		assert.Error(t, err)
+	})
+
+	t.Run("list provider versions not found", func(t *testing.T) {
+		_, err := registry.ListProviderVersionsWithResponse(ctx, "acme", "other")
+		assert.Error(t, err)
+	})
+
+	t.Run("get readme", func(t *testing.T) {
+		res, err := registry.GetProviderReadmeWithResponse(ctx, "acme", "test", "v0.2.0")
+		require.NoError(t, err)
+		assert.Equal(t, "# Test", res.JSON200.Readme)
+	})
+
+	t.Run("get asset", func(t *testing.T) {
+		res, err := http.Get(ts.URL + AssetsPrefix + "/acme/test/v0.2.0/handler.zip")
+		require.NoError(t, err)
+		defer res.Body.Close()
+		b, err := io.ReadAll(res.Body)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+		assert.Equal(t, "zip", string(b))
+	})
+
+	t.Run("only provider assets are served", func(t *testing.T) {
+		res, err := http.Get(ts.URL + AssetsPrefix + "/acme/test/v0.2.0/provider.json")
+		require.NoError(t, err)
+		defer res.Body.Close()
+		assert.Equal(t, http.StatusNotFound, res.StatusCode)
+	})
+}
+
+func TestServerRejectsPathTraversal(t *testing.T) {
+	parent := t.TempDir()
+	dir := filepath.Join(parent, "registry")
+	writeFile(t, filepath.Join(dir, "acme", "test", "v0.1.0", "provider.json"), `{}`)
+	// files outside of the registry directory must not be served.
+	writeFile(t, filepath.Join(parent, "outside", "v1", "provider.json"), `{}`)
+	writeFile(t, filepath.Join(parent, "outside", "v1", "handler.zip"), "zip")
+
+	ts := httptest.NewServer(New(dir).Handler())
+	defer ts.Close()
+
+	paths := []string{
+		"/v1alpha1/providers/../outside/v1",
+		"/v1alpha1/providers/%2e%2e/outside/v1",
+		"/v1alpha1/providers/../outside/v1/readme",
+		"/v1alpha1/providers/*/*",
+		"/v1alpha1/providers/acme/t%3Fst",
+		AssetsPrefix + "/../outside/v1/handler.zip",
+	}
+
+	for _, p := range paths {
+		t.Run(p, func(t *testing.T) {
+			res, err := http.Get(ts.URL + p)
+			require.NoError(t, err)
+			defer res.Body.Close()
+			assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+		})
+	}
+}
@@ -0,0 +1,42 @@
+// Package output handles structured output formats for CLI commands.
+package output
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/urfave/cli/v2"
+)
+
+const (
+	// Table prints human-readable tables. This is the default.
+	Table = "table"
+	// JSON prints indented JSON, for piping into other tools.
+	JSON = "json"
+)
+
+// Flag returns the --output flag for commands which support structured output.
+// A new flag is returned each time, as urfave/cli flags hold parsing state and
+// can't be shared between commands.
+func Flag() *cli.StringFlag {
+	return &cli.StringFlag{Name: "output", Aliases: []string{"o"}, Value: Table, Usage: "Output format ('table' or 'json')"}
+}
+
+// Format returns the output format selected with the --output flag.
+// It returns an error if the format isn't supported.
+func Format(c *cli.Context) (string, error) {
+	f := c.String("output")
+	switch f {
+	case Table, JSON:
+		return f, nil
+	}
+	return "", fmt.Errorf("unsupported output format '%s': supported formats are '%s' and '%s'", f, Table, JSON)
+}
+
+// PrintJSON writes v to w as indented JSON.
+func PrintJSON(w io.Writer, v interface{}) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
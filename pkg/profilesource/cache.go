@@ -0,0 +1,110 @@
+package profilesource
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Cache stores the accounts and roles available for each Access Rule version
+// on disk, so that repeated profile generation doesn't need to fetch
+// the details of every Access Rule.
+type Cache struct {
+	// Path is the JSON file the cache is stored in.
+	Path string
+	// TTL is how long cache entries are valid for.
+	TTL time.Duration
+
+	mu      sync.Mutex
+	entries map[string]cacheEntry
+	// now is overridden in tests.
+	now func() time.Time
+}
+
+type cacheEntry struct {
+	CachedAt time.Time     `json:"cachedAt"`
+	Roles    []accountRole `json:"roles"`
+}
+
+// accountRole is an account and permission set
+// that can be requested with an Access Rule.
+type accountRole struct {
+	AccountID   string `json:"accountId"`
+	AccountName string `json:"accountName"`
+	RoleName    string `json:"roleName"`
+}
+
+// NewCache loads the cache from path. If the cache file doesn't exist
+// or can't be read, an empty cache is returned.
+func NewCache(path string, ttl time.Duration) *Cache {
+	c := &Cache{
+		Path:    path,
+		TTL:     ttl,
+		entries: map[string]cacheEntry{},
+		now:     time.Now,
+	}
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return c
+	}
+
+	// an invalid cache file is ignored and will be overwritten by Save().
+	_ = json.Unmarshal(b, &c.entries)
+	return c
+}
+
+// DefaultCachePath returns the cache file path for a CLI context,
+// which is stored in the ~/.commonfate/cache folder.
+func DefaultCachePath(context string) (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".commonfate", "cache", "profiles-"+context+".json"), nil
+}
+
+// get returns the cached roles for the key if they haven't expired.
+func (c *Cache) get(key string) ([]accountRole, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e, ok := c.entries[key]
+	if !ok || c.now().Sub(e.CachedAt) > c.TTL {
+		return nil, false
+	}
+	return e.Roles, true
+}
+
+func (c *Cache) set(key string, roles []accountRole) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.entries[key] = cacheEntry{CachedAt: c.now(), Roles: roles}
+}
+
+// Save writes the cache to disk, removing any expired entries.
+func (c *Cache) Save() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for k, e := range c.entries {
+		if c.now().Sub(e.CachedAt) > c.TTL {
+			delete(c.entries, k)
+		}
+	}
+
+	b, err := json.Marshal(c.entries)
+	if err != nil {
+		return err
+	}
+
+	err = os.MkdirAll(filepath.Dir(c.Path), 0700)
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(c.Path, b, 0600)
+}
@@ -0,0 +1,49 @@
+package profilesource
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCache(t *testing.T) {
+	now := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	roles := []accountRole{{AccountID: "123456789012", AccountName: "prod", RoleName: "Admin"}}
+
+	type testcase struct {
+		name    string
+		elapsed time.Duration
+		wantOK  bool
+	}
+
+	testcases := []testcase{
+		{name: "fresh entry is returned", elapsed: time.Minute, wantOK: true},
+		{name: "expired entry is not returned", elapsed: 2 * time.Hour, wantOK: false},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "cache", "profiles.json")
+
+			c := NewCache(path, time.Hour)
+			c.now = func() time.Time { return now }
+			c.set("rule@v1", roles)
+			err := c.Save()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// reload the cache from disk
+			c = NewCache(path, time.Hour)
+			c.now = func() time.Time { return now.Add(tc.elapsed) }
+
+			got, ok := c.get("rule@v1")
+			assert.Equal(t, tc.wantOK, ok)
+			if tc.wantOK {
+				assert.Equal(t, roles, got)
+			}
+		})
+	}
+}
@@ -2,11 +2,20 @@ package profilesource
 
 import (
 	"context"
+	"sort"
+	"sync"
 
 	"github.com/common-fate/awsconfigfile"
+	"github.com/common-fate/clio"
+	"github.com/common-fate/common-fate/pkg/types"
 	"github.com/common-fate/glide-cli/pkg/client"
+	"golang.org/x/sync/errgroup"
 )
 
+// DefaultConcurrency is the default number of Access Rules
+// which are fetched at the same time.
+const DefaultConcurrency = 10
+
 // Source reads available AWS SSO profiles from the Common Fate API.
 // It implements the awsconfigfile.Source interface
 type Source struct {
@@ -14,6 +23,12 @@ type Source struct {
 	StartURL     string
 	Client       *client.Client
 	DashboardURL string
+	// Concurrency is the maximum number of Access Rules fetched at once.
+	// If zero, DefaultConcurrency is used.
+	Concurrency int
+	// Cache optionally caches the accounts and roles for each Access Rule.
+	// If nil, the details of every Access Rule are fetched.
+	Cache *Cache
 }
 
 func (s Source) GetProfiles(ctx context.Context) ([]awsconfigfile.SSOProfile, error) {
@@ -23,37 +38,161 @@ func (s Source) GetProfiles(ctx context.Context) ([]awsconfigfile.SSOProfile, er
 	if err != nil {
 		return nil, err
 	}
-	var profiles []awsconfigfile.SSOProfile
+
+	concurrency := s.Concurrency
+	if concurrency == 0 {
+		concurrency = DefaultConcurrency
+	}
+
+	var (
+		mu sync.Mutex
+		// roles is keyed by account ID and role name, so that accounts and
+		// roles available through multiple Access Rules are only included once.
+		roles = map[string]accountRole{}
+	)
+
+	g, gctx := errgroup.WithContext(ctx)
+	g.SetLimit(concurrency)
 
 	for _, r := range rules.JSON200.AccessRules {
-		ruleDetail, err := cf.UserGetAccessRuleWithResponse(ctx, r.ID)
+		r := r
+		g.Go(func() error {
+			ruleRoles, err := s.getRuleRoles(gctx, r)
+			if err != nil {
+				return err
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			for _, role := range ruleRoles {
+				key := role.AccountID + "/" + role.RoleName
+				existing, ok := roles[key]
+				// the same account may be labelled differently between Access Rules,
+				// so pick a name consistently regardless of the order rules are fetched in.
+				if ok && existing.AccountName <= role.AccountName {
+					continue
+				}
+				roles[key] = role
+			}
+			return nil
+		})
+	}
+
+	err = g.Wait()
+	if err != nil {
+		return nil, err
+	}
+
+	if s.Cache != nil {
+		err = s.Cache.Save()
 		if err != nil {
-			return nil, err
+			clio.Debugw("error saving profile cache", "error", err)
 		}
+	}
+
+	var profiles []awsconfigfile.SSOProfile
+	for _, role := range roles {
+		profiles = append(profiles, awsconfigfile.SSOProfile{
+			AccountID:     role.AccountID,
+			AccountName:   role.AccountName,
+			RoleName:      role.RoleName,
+			SSOStartURL:   s.StartURL,
+			SSORegion:     s.SSORegion,
+			GeneratedFrom: "commonfate",
+			CommonFateURL: s.DashboardURL,
+		})
+	}
 
-		// only rules for the aws-sso Access Provider are relevant here
-		if ruleDetail.JSON200.Target.Provider.Type != "aws-sso" {
-			continue
+	// sort profiles so that they are written in a consistent order.
+	sort.Slice(profiles, func(i, j int) bool {
+		if profiles[i].AccountName != profiles[j].AccountName {
+			return profiles[i].AccountName < profiles[j].AccountName
 		}
+		return profiles[i].RoleName < profiles[j].RoleName
+	})
 
-		accountId := ruleDetail.JSON200.Target.Arguments.AdditionalProperties["accountId"]
-		permissionSetArn := ruleDetail.JSON200.Target.Arguments.AdditionalProperties["permissionSetArn"]
-
-		// add all options to our profile map
-		for _, acc := range accountId.Options {
-			for _, ps := range permissionSetArn.Options {
-				p := awsconfigfile.SSOProfile{
-					AccountID:     acc.Value,
-					AccountName:   acc.Label,
-					RoleName:      ps.Label,
-					SSOStartURL:   s.StartURL,
-					SSORegion:     s.SSORegion,
-					GeneratedFrom: "commonfate",
-					CommonFateURL: s.DashboardURL,
-				}
-				profiles = append(profiles, p)
-			}
+	return profiles, nil
+}
+
+// getRuleRoles returns the accounts and roles which can be requested with an Access Rule,
+// using the cache if the Access Rule version has been fetched recently.
+func (s Source) getRuleRoles(ctx context.Context, rule types.AccessRule) ([]accountRole, error) {
+	if !isAWSTarget(rule.Target) {
+		return nil, nil
+	}
+
+	cacheKey := rule.ID + "@" + rule.Version
+	if s.Cache != nil {
+		if roles, ok := s.Cache.get(cacheKey); ok {
+			clio.Debugw("using cached Access Rule profiles", "rule", rule.ID, "version", rule.Version)
+			return roles, nil
 		}
 	}
-	return profiles, nil
+
+	ruleDetail, err := s.Client.UserGetAccessRuleWithResponse(ctx, rule.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	roles := rolesFromArguments(ruleDetail.JSON200.Target.Arguments.AdditionalProperties)
+	if len(roles) == 0 {
+		clio.Debugw("Access Rule does not grant access to AWS accounts, skipping", "rule", rule.ID, "targetGroup", rule.Target.Provider.Id)
+	}
+
+	if s.Cache != nil {
+		s.Cache.set(cacheKey, roles)
+	}
+
+	return roles, nil
+}
+
+// isAWSTarget returns true if an Access Rule may grant access to AWS accounts.
+//
+// Rules using the built-in aws-sso provider are always included. Rules for
+// registry providers (such as common-fate/aws) are linked to a Target Group
+// and don't have a provider type. The Target Group kind is only visible to
+// administrators, so these rules are included if their arguments match the
+// AWS account schema (see rolesFromArguments).
+func isAWSTarget(target types.AccessRuleTarget) bool {
+	if target.Provider.Type == "aws-sso" {
+		return true
+	}
+	return target.Provider.Type == "" && target.Provider.Id != ""
+}
+
+// rolesFromArguments returns the accounts and roles that can be requested
+// from the request arguments of an Access Rule. Both the built-in aws-sso
+// provider and the common-fate/aws Account kind use the accountId and
+// permissionSetArn arguments. If either argument is missing, the rule doesn't
+// grant access to AWS accounts and no roles are returned.
+func rolesFromArguments(args map[string]types.RequestArgument) []accountRole {
+	accountId, ok := args["accountId"]
+	if !ok {
+		return nil
+	}
+	permissionSetArn, ok := args["permissionSetArn"]
+	if !ok {
+		return nil
+	}
+
+	roles := []accountRole{}
+	for _, acc := range accountId.Options {
+		for _, ps := range permissionSetArn.Options {
+			roles = append(roles, accountRole{
+				AccountID:   acc.Value,
+				AccountName: labelOrValue(acc),
+				RoleName:    labelOrValue(ps),
+			})
+		}
+	}
+	return roles
+}
+
+// labelOrValue returns the label of an option, falling back to the value
+// for providers which don't label their options.
+func labelOrValue(o types.WithOption) string {
+	if o.Label != "" {
+		return o.Label
+	}
+	return o.Value
 }
@@ -0,0 +1,49 @@
+package profilesource
+
+import (
+	"testing"
+
+	"github.com/common-fate/common-fate/pkg/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRolesFromArguments(t *testing.T) {
+	type testcase struct {
+		name string
+		args map[string]types.RequestArgument
+		want []accountRole
+	}
+
+	testcases := []testcase{
+		{
+			name: "account and permission set",
+			args: map[string]types.RequestArgument{
+				"accountId":        {Options: []types.WithOption{{Label: "prod", Value: "123456789012"}}},
+				"permissionSetArn": {Options: []types.WithOption{{Label: "Admin", Value: "arn:aws:sso:::permissionSet/ssoins-1/ps-1"}}},
+			},
+			want: []accountRole{{AccountID: "123456789012", AccountName: "prod", RoleName: "Admin"}},
+		},
+		{
+			name: "unlabelled account falls back to value",
+			args: map[string]types.RequestArgument{
+				"accountId":        {Options: []types.WithOption{{Value: "123456789012"}}},
+				"permissionSetArn": {Options: []types.WithOption{{Label: "Admin", Value: "arn:aws:sso:::permissionSet/ssoins-1/ps-1"}}},
+			},
+			want: []accountRole{{AccountID: "123456789012", AccountName: "123456789012", RoleName: "Admin"}},
+		},
+		{
+			name: "non-AWS target group",
+			args: map[string]types.RequestArgument{
+				"group": {Options: []types.WithOption{{Label: "Engineering", Value: "eng"}}},
+			},
+			want: nil,
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := rolesFromArguments(tc.args)
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
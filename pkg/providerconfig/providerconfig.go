@@ -0,0 +1,92 @@
+// Package providerconfig validates provider config values
+// against the config schema of a provider.
+package providerconfig
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/AlecAivazis/survey/v2"
+	"github.com/common-fate/provider-registry-sdk-go/pkg/providerregistrysdk"
+	"github.com/pkg/errors"
+)
+
+// Schema returns the config schema of a provider,
+// which is empty if the provider doesn't require config.
+func Schema(p providerregistrysdk.ProviderDetail) map[string]providerregistrysdk.Config {
+	if p.Schema.Config == nil {
+		return map[string]providerregistrysdk.Config{}
+	}
+	return *p.Schema.Config
+}
+
+// Keys returns the sorted config keys in a schema.
+func Keys(schema map[string]providerregistrysdk.Config) []string {
+	var keys []string
+	for k := range schema {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// IsSecret returns true if a config value is a secret.
+func IsSecret(c providerregistrysdk.Config) bool {
+	return c.Secret != nil && *c.Secret
+}
+
+// Validate returns an error if a config value is empty, or can't be parsed as
+// the type declared in its schema (number, integer or boolean).
+//
+// Every config key is a parameter of the provider's CloudFormation template,
+// so a value is required. No other checks are done: values such as ARNs,
+// URLs or regions are not validated.
+func Validate(c providerregistrysdk.Config, value string) error {
+	if value == "" {
+		return errors.New("a value is required")
+	}
+
+	switch c.Type {
+	case providerregistrysdk.ConfigTypeString, "":
+	case "number":
+		if _, err := strconv.ParseFloat(value, 64); err != nil {
+			return fmt.Errorf("'%s' is not a number", value)
+		}
+	case "integer":
+		if _, err := strconv.Atoi(value); err != nil {
+			return fmt.Errorf("'%s' is not an integer", value)
+		}
+	case "boolean":
+		if _, err := strconv.ParseBool(value); err != nil {
+			return fmt.Errorf("'%s' is not a boolean (expected 'true' or 'false')", value)
+		}
+	default:
+		// types added to the schema after this version of the CLI are not validated.
+	}
+
+	return nil
+}
+
+// Validator returns a survey validator for a config value.
+func Validator(c providerregistrysdk.Config) survey.Validator {
+	return func(ans interface{}) error {
+		s, ok := ans.(string)
+		if !ok {
+			return fmt.Errorf("expected a string but got %T", ans)
+		}
+		return Validate(c, s)
+	}
+}
+
+// Help returns the help text shown when prompting for a config value,
+// which is the description from the schema followed by any notes.
+func Help(c providerregistrysdk.Config, notes ...string) string {
+	var lines []string
+	if c.Description != nil && *c.Description != "" {
+		lines = append(lines, *c.Description)
+	}
+	lines = append(lines, notes...)
+	return strings.Join(lines, "\n")
+}
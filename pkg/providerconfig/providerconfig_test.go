@@ -0,0 +1,37 @@
+package providerconfig
+
+import (
+	"testing"
+
+	"github.com/common-fate/provider-registry-sdk-go/pkg/providerregistrysdk"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidate(t *testing.T) {
+	type testcase struct {
+		name    string
+		config  providerregistrysdk.Config
+		value   string
+		wantErr string
+	}
+
+	str := providerregistrysdk.Config{Type: providerregistrysdk.ConfigTypeString}
+
+	testcases := []testcase{
+		{name: "string", config: str, value: "o-123456"},
+		{name: "empty value", config: str, value: "", wantErr: "a value is required"},
+		{name: "invalid boolean", config: providerregistrysdk.Config{Type: "boolean"}, value: "yes", wantErr: "'yes' is not a boolean (expected 'true' or 'false')"},
+		{name: "unknown types are not validated", config: providerregistrysdk.Config{Type: "object"}, value: "{}"},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := Validate(tc.config, tc.value)
+			if tc.wantErr == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, tc.wantErr)
+			}
+		})
+	}
+}
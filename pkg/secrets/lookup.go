@@ -0,0 +1,97 @@
+package secrets
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+	ssmtypes "github.com/aws/aws-sdk-go-v2/service/ssm/types"
+)
+
+// Info is metadata about a stored secret. It never contains the secret value.
+type Info struct {
+	Name string
+	// Exists is false if the referenced secret doesn't exist.
+	Exists       bool
+	LastModified time.Time
+	// KMSKeyID is the KMS key the secret is encrypted with, if it is known.
+	KMSKeyID string
+}
+
+// Reference returns the reference to the secret which is passed to the Handler.
+func (i Info) Reference() string {
+	return SSMPrefix + i.Name
+}
+
+// ParseReference returns the name of the SSM parameter that a secret reference points to.
+func ParseReference(ref string) (string, error) {
+	if !strings.HasPrefix(ref, SSMPrefix) {
+		return "", fmt.Errorf("'%s' is not a secret reference (expected a '%s' prefix)", ref, SSMPrefix)
+	}
+	return strings.TrimPrefix(ref, SSMPrefix), nil
+}
+
+// Lookup returns metadata about the secret that a reference points to.
+func Lookup(ctx context.Context, cfg aws.Config, ref string) (Info, error) {
+	name, err := ParseReference(ref)
+	if err != nil {
+		return Info{}, err
+	}
+
+	params, err := describeSSMParameters(ctx, cfg, ssmtypes.ParameterStringFilter{
+		Key:    aws.String("Name"),
+		Option: aws.String("Equals"),
+		Values: []string{name},
+	})
+	if err != nil {
+		return Info{}, err
+	}
+	if len(params) == 0 {
+		return Info{Name: name}, nil
+	}
+	return params[0], nil
+}
+
+// ListSSM returns the SSM parameters stored under a path, such as the
+// prefix returned by ssmkey.Prefix.
+func ListSSM(ctx context.Context, cfg aws.Config, path string) ([]Info, error) {
+	return describeSSMParameters(ctx, cfg, ssmtypes.ParameterStringFilter{
+		Key:    aws.String("Path"),
+		Option: aws.String("Recursive"),
+		Values: []string{strings.TrimSuffix(path, "/")},
+	})
+}
+
+// describeSSMParameters uses DescribeParameters rather than GetParameter,
+// so that the parameter values are never read.
+func describeSSMParameters(ctx context.Context, cfg aws.Config, filter ssmtypes.ParameterStringFilter) ([]Info, error) {
+	var res []Info
+
+	p := ssm.NewDescribeParametersPaginator(ssm.NewFromConfig(cfg), &ssm.DescribeParametersInput{
+		ParameterFilters: []ssmtypes.ParameterStringFilter{filter},
+	})
+	for p.HasMorePages() {
+		page, err := p.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, param := range page.Parameters {
+			res = append(res, Info{
+				Name:         aws.ToString(param.Name),
+				Exists:       true,
+				LastModified: aws.ToTime(param.LastModifiedDate),
+				KMSKeyID:     aws.ToString(param.KeyId),
+			})
+		}
+	}
+	return res, nil
+}
+
+// WriterFor returns a Writer which updates an existing secret,
+// keeping the KMS key that the secret is encrypted with.
+func WriterFor(cfg aws.Config, info Info) Writer {
+	return NewSSMWriter(cfg, info.KMSKeyID)
+}
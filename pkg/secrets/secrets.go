@@ -0,0 +1,59 @@
+// Package secrets writes provider secrets to AWS,
+// so that they can be read by the provider's Handler.
+package secrets
+
+import (
+	"context"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/urfave/cli/v2"
+)
+
+// SSMPrefix is the prefix of references to SSM parameters,
+// which is the only reference scheme that Handlers can resolve.
+const SSMPrefix = "awsssm://"
+
+// Writer writes secrets to a backend. Secrets are passed to the Handler
+// as references, so the secret values are never stored in CloudFormation.
+type Writer interface {
+	// Write creates or updates a secret.
+	Write(ctx context.Context, name string, value string) error
+	// Reference returns the reference to a secret which is passed to the Handler.
+	Reference(name string) string
+	// Description describes where a secret is stored, for prompts and log messages.
+	Description(name string) string
+}
+
+// Opts configures how secrets are stored.
+type Opts struct {
+	// KMSKeyID is an optional customer-managed KMS key to encrypt secrets with.
+	// If empty, the AWS managed key for SSM is used.
+	KMSKeyID string
+}
+
+// New returns a Writer which stores secrets as SSM parameters.
+func New(cfg aws.Config, opts Opts) Writer {
+	return NewSSMWriter(cfg, opts.KMSKeyID)
+}
+
+// IsReference returns true if a value is a reference to a secret, rather than the secret itself.
+func IsReference(value string) bool {
+	return strings.HasPrefix(value, SSMPrefix)
+}
+
+// Flags returns the flags for configuring how secrets are stored.
+// New flags are returned each time, as urfave/cli flags hold parsing state and
+// can't be shared between commands.
+func Flags() []cli.Flag {
+	return []cli.Flag{
+		&cli.StringFlag{Name: "secret-kms-key-id", Usage: "The ID or ARN of a customer-managed KMS key to encrypt secret config values with. The key policy must allow the Handler's IAM role to use kms:Decrypt, otherwise the Handler can't read its secrets"},
+	}
+}
+
+// OptsFromFlags returns the options selected with the flags from Flags().
+func OptsFromFlags(c *cli.Context) Opts {
+	return Opts{
+		KMSKeyID: c.String("secret-kms-key-id"),
+	}
+}
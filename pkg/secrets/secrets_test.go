@@ -0,0 +1,61 @@
+package secrets
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReference(t *testing.T) {
+	type testcase struct {
+		name    string
+		opts    Opts
+		wantRef string
+	}
+
+	testcases := []testcase{
+		{name: "default", opts: Opts{}, wantRef: "awsssm:///common-fate/provider/common-fate/aws/cf-handler-aws/apiToken"},
+		{name: "with kms key", opts: Opts{KMSKeyID: "alias/common-fate"}, wantRef: "awsssm:///common-fate/provider/common-fate/aws/cf-handler-aws/apiToken"},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			w := New(aws.Config{Region: "us-east-1"}, tc.opts)
+
+			ref := w.Reference("/common-fate/provider/common-fate/aws/cf-handler-aws/apiToken")
+			assert.Equal(t, tc.wantRef, ref)
+			assert.True(t, IsReference(ref))
+		})
+	}
+}
+
+func TestParseReference(t *testing.T) {
+	type testcase struct {
+		name     string
+		ref      string
+		wantName string
+		wantErr  string
+	}
+
+	testcases := []testcase{
+		{name: "ssm", ref: "awsssm:///common-fate/provider/common-fate/aws/cf-handler-aws/apiToken", wantName: "/common-fate/provider/common-fate/aws/cf-handler-aws/apiToken"},
+		{name: "not a reference", ref: "/common-fate/apiToken", wantErr: "'/common-fate/apiToken' is not a secret reference (expected a 'awsssm://' prefix)"},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			name, err := ParseReference(tc.ref)
+			if tc.wantErr != "" {
+				assert.EqualError(t, err, tc.wantErr)
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			assert.Equal(t, tc.wantName, name)
+
+			assert.Equal(t, tc.ref, Info{Name: name}.Reference())
+		})
+	}
+}
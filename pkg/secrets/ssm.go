@@ -0,0 +1,70 @@
+package secrets
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+	"github.com/aws/aws-sdk-go-v2/service/ssm/types"
+)
+
+// SSMWriter writes secrets as SecureString parameters in AWS SSM Parameter Store.
+type SSMWriter struct {
+	client *ssm.Client
+	// kmsKeyID is used to encrypt the parameters, if set.
+	kmsKeyID string
+}
+
+// NewSSMWriter returns a Writer for AWS SSM Parameter Store. If kmsKeyID
+// is empty, parameters are encrypted with the AWS managed key.
+func NewSSMWriter(cfg aws.Config, kmsKeyID string) *SSMWriter {
+	return &SSMWriter{client: ssm.NewFromConfig(cfg), kmsKeyID: kmsKeyID}
+}
+
+func (w *SSMWriter) Write(ctx context.Context, name string, value string) error {
+	in := ssm.PutParameterInput{
+		Name:      &name,
+		Value:     &value,
+		Type:      types.ParameterTypeSecureString,
+		Overwrite: aws.Bool(true),
+	}
+	if w.kmsKeyID != "" {
+		in.KeyId = &w.kmsKeyID
+	}
+
+	_, err := w.client.PutParameter(ctx, &in)
+	return err
+}
+
+func (w *SSMWriter) Reference(name string) string {
+	return SSMPrefix + name
+}
+
+func (w *SSMWriter) Description(name string) string {
+	if w.kmsKeyID != "" {
+		return fmt.Sprintf("AWS SSM Parameter Store with name '%s' (encrypted with KMS key '%s')", name, w.kmsKeyID)
+	}
+	return fmt.Sprintf("AWS SSM Parameter Store with name '%s'", name)
+}
+
+// DeleteSSM deletes SSM parameters, such as those returned by ListSSM.
+// Parameters which don't exist are ignored.
+func DeleteSSM(ctx context.Context, cfg aws.Config, names []string) error {
+	client := ssm.NewFromConfig(cfg)
+
+	// DeleteParameters accepts at most 10 names per call.
+	for start := 0; start < len(names); start += 10 {
+		end := start + 10
+		if end > len(names) {
+			end = len(names)
+		}
+		_, err := client.DeleteParameters(ctx, &ssm.DeleteParametersInput{
+			Names: names[start:end],
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
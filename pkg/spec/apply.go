@@ -0,0 +1,110 @@
+package spec
+
+import (
+	"context"
+
+	"github.com/common-fate/clio"
+	"github.com/common-fate/common-fate/pkg/types"
+	"github.com/common-fate/glide-cli/pkg/client"
+	"github.com/common-fate/provider-registry-sdk-go/pkg/providerregistrysdk"
+	"github.com/pkg/errors"
+)
+
+// Apply makes the planned changes using the admin API.
+// Changes are applied in order, stopping at the first error.
+func Apply(ctx context.Context, cf *client.Client, changes []Change) error {
+	for _, c := range changes {
+		clio.Infof("%s %s %s", actionVerb(c.Action), c.Resource(), c.ID())
+
+		err := applyChange(ctx, cf, c)
+		if err != nil {
+			return errors.Wrapf(err, "%s %s %s", c.Action, c.Resource(), c.ID())
+		}
+	}
+	return nil
+}
+
+func applyChange(ctx context.Context, cf *client.Client, c Change) error {
+	switch {
+	case c.TargetGroup != nil:
+		if c.Action == Delete || c.Action == Replace {
+			_, err := cf.AdminDeleteTargetGroupWithResponse(ctx, c.TargetGroup.ID)
+			if err != nil {
+				return err
+			}
+		}
+		if c.Action == Create || c.Action == Replace {
+			return createTargetGroup(ctx, cf, *c.TargetGroup)
+		}
+
+	case c.Handler != nil:
+		if c.Action == Delete || c.Action == Replace {
+			_, err := cf.AdminDeleteHandlerWithResponse(ctx, c.Handler.ID)
+			if err != nil {
+				return err
+			}
+		}
+		if c.Action == Create || c.Action == Replace {
+			_, err := cf.AdminRegisterHandlerWithResponse(ctx, types.AdminRegisterHandlerJSONRequestBody{
+				Id:         c.Handler.ID,
+				AwsAccount: c.Handler.AWSAccount,
+				AwsRegion:  c.Handler.AWSRegion,
+				Runtime:    c.Handler.Runtime,
+			})
+			return err
+		}
+
+	case c.Link != nil:
+		if c.Action == Delete || c.Action == Update {
+			_, err := cf.AdminRemoveTargetGroupLinkWithResponse(ctx, c.Link.TargetGroup, &types.AdminRemoveTargetGroupLinkParams{
+				DeploymentId: c.Link.Handler,
+				Kind:         c.Link.Kind,
+			})
+			if err != nil {
+				return err
+			}
+		}
+		if c.Action == Create || c.Action == Update {
+			_, err := cf.AdminCreateTargetGroupLinkWithResponse(ctx, c.Link.TargetGroup, types.AdminCreateTargetGroupLinkJSONRequestBody{
+				DeploymentId: c.Link.Handler,
+				Kind:         c.Link.Kind,
+				Priority:     c.Link.Priority,
+			})
+			return err
+		}
+	}
+
+	return nil
+}
+
+func createTargetGroup(ctx context.Context, cf *client.Client, tg TargetGroup) error {
+	p, err := providerregistrysdk.ParseProvider(tg.Provider)
+	if err != nil {
+		return err
+	}
+
+	_, err = cf.AdminCreateTargetGroupWithResponse(ctx, types.AdminCreateTargetGroupJSONRequestBody{
+		Id: tg.ID,
+		From: types.TargetGroupFrom{
+			Publisher: p.Publisher,
+			Name:      p.Name,
+			Version:   p.Version,
+			Kind:      tg.Kind,
+		},
+	})
+	return err
+}
+
+// actionVerb returns the present participle of an action for log messages.
+func actionVerb(a Action) string {
+	switch a {
+	case Create:
+		return "Creating"
+	case Update:
+		return "Updating"
+	case Replace:
+		return "Replacing"
+	default:
+		return "Deleting"
+	}
+}
@@ -0,0 +1,194 @@
+package spec
+
+import (
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/common-fate/glide-cli/pkg/table"
+)
+
+// Action is the type of change made to a resource.
+type Action string
+
+const (
+	Create Action = "create"
+	// Update is only used for links, which are updated by unlinking
+	// and relinking the Handler.
+	Update Action = "update"
+	// Replace is used for Target Groups and Handlers, which can't be
+	// updated through the API and are deleted and created again.
+	Replace Action = "replace"
+	Delete  Action = "delete"
+)
+
+// Change is a change to a single resource. Exactly one of
+// TargetGroup, Handler or Link is set.
+type Change struct {
+	Action      Action
+	TargetGroup *TargetGroup
+	Handler     *Handler
+	Link        *Link
+}
+
+// Resource returns the type of resource being changed.
+func (c Change) Resource() string {
+	switch {
+	case c.TargetGroup != nil:
+		return "target group"
+	case c.Handler != nil:
+		return "handler"
+	default:
+		return "link"
+	}
+}
+
+// ID returns the ID of the resource being changed.
+func (c Change) ID() string {
+	switch {
+	case c.TargetGroup != nil:
+		return c.TargetGroup.ID
+	case c.Handler != nil:
+		return c.Handler.ID
+	default:
+		return c.Link.TargetGroup + " -> " + c.Link.Handler
+	}
+}
+
+// Details returns a summary of the desired state of the resource.
+func (c Change) Details() string {
+	switch {
+	case c.TargetGroup != nil:
+		return fmt.Sprintf("provider=%s kind=%s", c.TargetGroup.Provider, c.TargetGroup.Kind)
+	case c.Handler != nil:
+		return fmt.Sprintf("account=%s region=%s runtime=%s", c.Handler.AWSAccount, c.Handler.AWSRegion, c.Handler.Runtime)
+	default:
+		return fmt.Sprintf("kind=%s priority=%d", c.Link.Kind, c.Link.Priority)
+	}
+}
+
+// Plan returns the changes needed to converge the current state to the desired state.
+//
+// Changes are ordered so that they can be applied sequentially: links are removed
+// first, then Target Groups and Handlers are deleted, replaced and created, and
+// finally links are created and updated.
+func Plan(desired, current Spec) []Change {
+	var (
+		linkDeletes     []Change
+		resourceDeletes []Change
+		replaces        []Change
+		creates         []Change
+		linkCreates     []Change
+	)
+
+	// replaced tracks Target Groups and Handlers which are deleted and
+	// created again, which means that their links need to be recreated.
+	replaced := map[string]bool{}
+
+	currentTargetGroups := map[string]TargetGroup{}
+	for _, tg := range current.TargetGroups {
+		currentTargetGroups[tg.ID] = tg
+	}
+	desiredTargetGroups := map[string]bool{}
+	for _, tg := range desired.TargetGroups {
+		tg := tg
+		desiredTargetGroups[tg.ID] = true
+		existing, ok := currentTargetGroups[tg.ID]
+		switch {
+		case !ok:
+			creates = append(creates, Change{Action: Create, TargetGroup: &tg})
+		case existing != tg:
+			replaced["tg/"+tg.ID] = true
+			replaces = append(replaces, Change{Action: Replace, TargetGroup: &tg})
+		}
+	}
+	for _, tg := range current.TargetGroups {
+		tg := tg
+		if !desiredTargetGroups[tg.ID] {
+			resourceDeletes = append(resourceDeletes, Change{Action: Delete, TargetGroup: &tg})
+		}
+	}
+
+	currentHandlers := map[string]Handler{}
+	for _, h := range current.Handlers {
+		currentHandlers[h.ID] = h
+	}
+	desiredHandlers := map[string]bool{}
+	for _, h := range desired.Handlers {
+		h := h
+		desiredHandlers[h.ID] = true
+		existing, ok := currentHandlers[h.ID]
+		switch {
+		case !ok:
+			creates = append(creates, Change{Action: Create, Handler: &h})
+		case existing != h:
+			replaced["handler/"+h.ID] = true
+			replaces = append(replaces, Change{Action: Replace, Handler: &h})
+		}
+	}
+	for _, h := range current.Handlers {
+		h := h
+		if !desiredHandlers[h.ID] {
+			resourceDeletes = append(resourceDeletes, Change{Action: Delete, Handler: &h})
+		}
+	}
+
+	linkReplaced := func(l Link) bool {
+		return replaced["tg/"+l.TargetGroup] || replaced["handler/"+l.Handler]
+	}
+
+	currentLinks := map[string]Link{}
+	for _, l := range current.Links {
+		currentLinks[l.key()] = l
+	}
+	desiredLinks := map[string]bool{}
+	for _, l := range desired.Links {
+		l := l
+		desiredLinks[l.key()] = true
+		existing, ok := currentLinks[l.key()]
+		switch {
+		case !ok:
+			linkCreates = append(linkCreates, Change{Action: Create, Link: &l})
+		case linkReplaced(l):
+			// remove the link before the Target Group or Handler is replaced, and link it again afterwards.
+			linkDeletes = append(linkDeletes, Change{Action: Delete, Link: &existing})
+			linkCreates = append(linkCreates, Change{Action: Create, Link: &l})
+		case existing != l:
+			linkCreates = append(linkCreates, Change{Action: Update, Link: &l})
+		}
+	}
+	for _, l := range current.Links {
+		l := l
+		if !desiredLinks[l.key()] {
+			linkDeletes = append(linkDeletes, Change{Action: Delete, Link: &l})
+		}
+	}
+
+	var changes []Change
+	for _, group := range [][]Change{linkDeletes, resourceDeletes, replaces, creates, linkCreates} {
+		sortChanges(group)
+		changes = append(changes, group...)
+	}
+	return changes
+}
+
+// sortChanges sorts changes so that Target Groups are changed before Handlers,
+// and resources of the same type are changed in order of their ID.
+func sortChanges(changes []Change) {
+	sort.SliceStable(changes, func(i, j int) bool {
+		if changes[i].Resource() != changes[j].Resource() {
+			return changes[i].Resource() > changes[j].Resource()
+		}
+		return changes[i].ID() < changes[j].ID()
+	})
+}
+
+// PrintPlan writes a table of planned changes.
+func PrintPlan(w io.Writer, changes []Change) error {
+	t := table.New(w)
+	t.Columns("ACTION", "RESOURCE", "ID", "DETAILS")
+	for _, c := range changes {
+		t.Row(string(c.Action), c.Resource(), c.ID(), c.Details())
+	}
+	return t.Flush()
+}
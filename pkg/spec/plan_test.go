@@ -0,0 +1,87 @@
+package spec
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPlan(t *testing.T) {
+	tg := TargetGroup{ID: "aws", Provider: "common-fate/aws@v0.4.0", Kind: "Account"}
+	handler := Handler{ID: "cf-handler-aws", AWSAccount: "123456789012", AWSRegion: "us-east-1", Runtime: "aws-lambda"}
+	link := Link{TargetGroup: "aws", Handler: "cf-handler-aws", Kind: "Account", Priority: 100}
+
+	upgradedTG := tg
+	upgradedTG.Provider = "common-fate/aws@v0.5.0"
+
+	reprioritised := link
+	reprioritised.Priority = 200
+
+	type change struct {
+		Action   Action
+		Resource string
+		ID       string
+	}
+
+	type testcase struct {
+		name    string
+		desired Spec
+		current Spec
+		want    []change
+	}
+
+	testcases := []testcase{
+		{
+			name:    "no changes",
+			desired: Spec{TargetGroups: []TargetGroup{tg}, Handlers: []Handler{handler}, Links: []Link{link}},
+			current: Spec{TargetGroups: []TargetGroup{tg}, Handlers: []Handler{handler}, Links: []Link{link}},
+			want:    nil,
+		},
+		{
+			name:    "create everything",
+			desired: Spec{TargetGroups: []TargetGroup{tg}, Handlers: []Handler{handler}, Links: []Link{link}},
+			want: []change{
+				{Create, "target group", "aws"},
+				{Create, "handler", "cf-handler-aws"},
+				{Create, "link", "aws -> cf-handler-aws"},
+			},
+		},
+		{
+			name:    "delete everything",
+			current: Spec{TargetGroups: []TargetGroup{tg}, Handlers: []Handler{handler}, Links: []Link{link}},
+			want: []change{
+				{Delete, "link", "aws -> cf-handler-aws"},
+				{Delete, "target group", "aws"},
+				{Delete, "handler", "cf-handler-aws"},
+			},
+		},
+		{
+			name:    "update link priority",
+			desired: Spec{TargetGroups: []TargetGroup{tg}, Handlers: []Handler{handler}, Links: []Link{reprioritised}},
+			current: Spec{TargetGroups: []TargetGroup{tg}, Handlers: []Handler{handler}, Links: []Link{link}},
+			want: []change{
+				{Update, "link", "aws -> cf-handler-aws"},
+			},
+		},
+		{
+			name:    "replacing a target group relinks handlers",
+			desired: Spec{TargetGroups: []TargetGroup{upgradedTG}, Handlers: []Handler{handler}, Links: []Link{link}},
+			current: Spec{TargetGroups: []TargetGroup{tg}, Handlers: []Handler{handler}, Links: []Link{link}},
+			want: []change{
+				{Delete, "link", "aws -> cf-handler-aws"},
+				{Replace, "target group", "aws"},
+				{Create, "link", "aws -> cf-handler-aws"},
+			},
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			var got []change
+			for _, c := range Plan(tc.desired, tc.current) {
+				got = append(got, change{c.Action, c.Resource(), c.ID()})
+			}
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
@@ -0,0 +1,312 @@
+// Package spec describes Target Groups, Handlers and the links between
+// them in a declarative file, and plans the changes required to
+// converge a Common Fate deployment towards the file.
+package spec
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/common-fate/provider-registry-sdk-go/pkg/providerregistrysdk"
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// DefaultRuntime is the runtime used for Handlers which don't specify one.
+	DefaultRuntime = "aws-lambda"
+	// DefaultPriority is the priority used for links which don't specify one.
+	DefaultPriority = 100
+)
+
+// Spec is the desired set of Target Groups, Handlers and links.
+type Spec struct {
+	TargetGroups []TargetGroup `toml:"target_group" yaml:"targetGroups"`
+	Handlers     []Handler     `toml:"handler" yaml:"handlers"`
+	Links        []Link        `toml:"link" yaml:"links"`
+}
+
+// TargetGroup is a Target Group created from a provider kind.
+type TargetGroup struct {
+	ID string `toml:"id" yaml:"id"`
+	// Provider is the provider that the Target Group schema
+	// is created from, in 'publisher/name@version' format.
+	Provider string `toml:"provider" yaml:"provider"`
+	Kind     string `toml:"kind" yaml:"kind"`
+}
+
+// Handler is a deployed provider which is registered with Common Fate.
+type Handler struct {
+	ID         string `toml:"id" yaml:"id"`
+	AWSAccount string `toml:"aws_account" yaml:"awsAccount"`
+	AWSRegion  string `toml:"aws_region" yaml:"awsRegion"`
+	Runtime    string `toml:"runtime" yaml:"runtime"`
+}
+
+// Link routes requests for a Target Group to a Handler.
+type Link struct {
+	TargetGroup string `toml:"target_group" yaml:"targetGroup"`
+	Handler     string `toml:"handler" yaml:"handler"`
+	Kind        string `toml:"kind" yaml:"kind"`
+	Priority    int    `toml:"priority" yaml:"priority"`
+}
+
+// key uniquely identifies a link. A Handler may only be linked
+// to a Target Group once for each kind.
+func (l Link) key() string {
+	return l.TargetGroup + "/" + l.Handler + "/" + l.Kind
+}
+
+// Format is the file format of a spec.
+type Format string
+
+const (
+	TOML Format = "toml"
+	YAML Format = "yaml"
+)
+
+// FormatFromPath returns the format of a spec file based on its extension.
+// Files with a '.yaml' or '.yml' extension are YAML, and all other files are TOML.
+func FormatFromPath(path string) Format {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		return YAML
+	default:
+		return TOML
+	}
+}
+
+// Load reads and validates a spec file.
+func Load(path string) (*Spec, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var s Spec
+	switch FormatFromPath(path) {
+	case YAML:
+		err = yaml.Unmarshal(b, &s)
+	default:
+		_, err = toml.NewDecoder(bytes.NewReader(b)).Decode(&s)
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "parsing %s", path)
+	}
+
+	s.setDefaults()
+
+	err = s.Validate()
+	if err != nil {
+		return nil, errors.Wrapf(err, "validating %s", path)
+	}
+
+	return &s, nil
+}
+
+// setDefaults populates optional fields which were not specified.
+func (s *Spec) setDefaults() {
+	for i := range s.Handlers {
+		if s.Handlers[i].Runtime == "" {
+			s.Handlers[i].Runtime = DefaultRuntime
+		}
+	}
+	for i := range s.Links {
+		if s.Links[i].Priority == 0 {
+			s.Links[i].Priority = DefaultPriority
+		}
+	}
+}
+
+// Validate checks that required fields are set, that IDs are unique,
+// and that links refer to Target Groups and Handlers in the spec.
+func (s Spec) Validate() error {
+	targetGroups := map[string]bool{}
+	for _, tg := range s.TargetGroups {
+		if tg.ID == "" {
+			return errors.New("target group id is required")
+		}
+		if targetGroups[tg.ID] {
+			return fmt.Errorf("duplicate target group '%s'", tg.ID)
+		}
+		targetGroups[tg.ID] = true
+
+		_, err := providerregistrysdk.ParseProvider(tg.Provider)
+		if err != nil {
+			return errors.Wrapf(err, "target group '%s'", tg.ID)
+		}
+		if tg.Kind == "" {
+			return fmt.Errorf("target group '%s': kind is required", tg.ID)
+		}
+	}
+
+	handlers := map[string]bool{}
+	for _, h := range s.Handlers {
+		if h.ID == "" {
+			return errors.New("handler id is required")
+		}
+		if handlers[h.ID] {
+			return fmt.Errorf("duplicate handler '%s'", h.ID)
+		}
+		handlers[h.ID] = true
+
+		if h.AWSAccount == "" || h.AWSRegion == "" {
+			return fmt.Errorf("handler '%s': aws account and aws region are required", h.ID)
+		}
+	}
+
+	links := map[string]bool{}
+	for _, l := range s.Links {
+		if !targetGroups[l.TargetGroup] {
+			return fmt.Errorf("link refers to target group '%s' which is not in the spec", l.TargetGroup)
+		}
+		if !handlers[l.Handler] {
+			return fmt.Errorf("link refers to handler '%s' which is not in the spec", l.Handler)
+		}
+		if l.Kind == "" {
+			return fmt.Errorf("link from target group '%s' to handler '%s': kind is required", l.TargetGroup, l.Handler)
+		}
+		if links[l.key()] {
+			return fmt.Errorf("duplicate link from target group '%s' to handler '%s' with kind '%s'", l.TargetGroup, l.Handler, l.Kind)
+		}
+		links[l.key()] = true
+	}
+
+	return nil
+}
+
+// Write encodes the spec in the given format. Resources are sorted
+// so that exporting the same state produces the same file.
+func Write(w io.Writer, format Format, s Spec) error {
+	s.sort()
+
+	switch format {
+	case YAML:
+		enc := yaml.NewEncoder(w)
+		enc.SetIndent(2)
+		err := enc.Encode(s)
+		if err != nil {
+			return err
+		}
+		return enc.Close()
+	case TOML:
+		return toml.NewEncoder(w).Encode(s)
+	default:
+		return fmt.Errorf("unsupported spec format: %s", format)
+	}
+}
+
+// sort sorts Target Groups and Handlers by ID,
+// and links by Target Group, Handler and kind.
+func (s *Spec) sort() {
+	sort.Slice(s.TargetGroups, func(i, j int) bool {
+		return s.TargetGroups[i].ID < s.TargetGroups[j].ID
+	})
+	sort.Slice(s.Handlers, func(i, j int) bool {
+		return s.Handlers[i].ID < s.Handlers[j].ID
+	})
+	sort.Slice(s.Links, func(i, j int) bool {
+		return s.Links[i].key() < s.Links[j].key()
+	})
+}
+
+// PutTargetGroup adds a Target Group to the spec, replacing any Target Group with the same ID.
+func (s *Spec) PutTargetGroup(tg TargetGroup) {
+	for i := range s.TargetGroups {
+		if s.TargetGroups[i].ID == tg.ID {
+			s.TargetGroups[i] = tg
+			return
+		}
+	}
+	s.TargetGroups = append(s.TargetGroups, tg)
+}
+
+// PutHandler adds a Handler to the spec, replacing any Handler with the same ID.
+func (s *Spec) PutHandler(h Handler) {
+	for i := range s.Handlers {
+		if s.Handlers[i].ID == h.ID {
+			s.Handlers[i] = h
+			return
+		}
+	}
+	s.Handlers = append(s.Handlers, h)
+}
+
+// PutLink adds a link to the spec, replacing any link from the
+// same Target Group to the same Handler with the same kind.
+func (s *Spec) PutLink(l Link) {
+	for i := range s.Links {
+		if s.Links[i].key() == l.key() {
+			s.Links[i] = l
+			return
+		}
+	}
+	s.Links = append(s.Links, l)
+}
+
+// FindLink returns the link from a Target Group to a Handler for a particular kind.
+func (s Spec) FindLink(targetGroup, handler, kind string) (Link, bool) {
+	want := Link{TargetGroup: targetGroup, Handler: handler, Kind: kind}
+	for _, l := range s.Links {
+		if l.key() == want.key() {
+			return l, true
+		}
+	}
+	return Link{}, false
+}
+
+// RemoveTargetGroup removes a Target Group and its links from the spec.
+func (s *Spec) RemoveTargetGroup(id string) {
+	var tgs []TargetGroup
+	for _, tg := range s.TargetGroups {
+		if tg.ID != id {
+			tgs = append(tgs, tg)
+		}
+	}
+	s.TargetGroups = tgs
+	s.removeLinks(func(l Link) bool { return l.TargetGroup == id })
+}
+
+// RemoveHandler removes a Handler and its links from the spec.
+func (s *Spec) RemoveHandler(id string) {
+	var handlers []Handler
+	for _, h := range s.Handlers {
+		if h.ID != id {
+			handlers = append(handlers, h)
+		}
+	}
+	s.Handlers = handlers
+	s.removeLinks(func(l Link) bool { return l.Handler == id })
+}
+
+// RemoveLink removes the link from a Target Group to a Handler for a particular kind.
+func (s *Spec) RemoveLink(targetGroup, handler, kind string) {
+	want := Link{TargetGroup: targetGroup, Handler: handler, Kind: kind}
+	s.removeLinks(func(l Link) bool { return l.key() == want.key() })
+}
+
+func (s *Spec) removeLinks(match func(l Link) bool) {
+	var links []Link
+	for _, l := range s.Links {
+		if !match(l) {
+			links = append(links, l)
+		}
+	}
+	s.Links = links
+}
+
+// Copy returns a copy of the spec which can be modified
+// without changing the original.
+func (s Spec) Copy() Spec {
+	return Spec{
+		TargetGroups: append([]TargetGroup(nil), s.TargetGroups...),
+		Handlers:     append([]Handler(nil), s.Handlers...),
+		Links:        append([]Link(nil), s.Links...),
+	}
+}
@@ -0,0 +1,95 @@
+package spec
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWriteLoad(t *testing.T) {
+	s := Spec{
+		TargetGroups: []TargetGroup{{ID: "aws", Provider: "common-fate/aws@v0.4.0", Kind: "Account"}},
+		Handlers:     []Handler{{ID: "cf-handler-aws", AWSAccount: "123456789012", AWSRegion: "us-east-1", Runtime: DefaultRuntime}},
+		Links:        []Link{{TargetGroup: "aws", Handler: "cf-handler-aws", Kind: "Account", Priority: DefaultPriority}},
+	}
+
+	type testcase struct {
+		name string
+		file string
+	}
+
+	testcases := []testcase{
+		{name: "toml", file: "spec.toml"},
+		{name: "yaml", file: "spec.yaml"},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), tc.file)
+
+			var buf bytes.Buffer
+			err := Write(&buf, FormatFromPath(path), s)
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = os.WriteFile(path, buf.Bytes(), 0600)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := Load(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			assert.Equal(t, s, *got)
+		})
+	}
+}
+
+func TestPutIsIdempotent(t *testing.T) {
+	current := Spec{
+		TargetGroups: []TargetGroup{{ID: "aws", Provider: "common-fate/aws@v0.4.0", Kind: "Account"}},
+		Handlers:     []Handler{{ID: "cf-handler-aws", AWSAccount: "123456789012", AWSRegion: "us-east-1", Runtime: DefaultRuntime}},
+		Links:        []Link{{TargetGroup: "aws", Handler: "cf-handler-aws", Kind: "Account", Priority: 200}},
+	}
+
+	desired := current.Copy()
+	desired.PutTargetGroup(TargetGroup{ID: "aws", Provider: "common-fate/aws@v0.4.0", Kind: "Account"})
+	desired.PutHandler(Handler{ID: "cf-handler-aws", AWSAccount: "123456789012", AWSRegion: "us-east-1", Runtime: DefaultRuntime})
+
+	_, ok := desired.FindLink("aws", "cf-handler-aws", "Account")
+	assert.True(t, ok)
+	assert.Empty(t, Plan(desired, current))
+
+	desired.PutTargetGroup(TargetGroup{ID: "aws", Provider: "common-fate/aws@v0.5.0", Kind: "Account"})
+	assert.Equal(t, "common-fate/aws@v0.4.0", current.TargetGroups[0].Provider, "Copy should not share slices with the original spec")
+	assert.Len(t, desired.TargetGroups, 1)
+}
+
+func TestRemove(t *testing.T) {
+	current := Spec{
+		TargetGroups: []TargetGroup{{ID: "aws", Provider: "common-fate/aws@v0.4.0", Kind: "Account"}},
+		Handlers: []Handler{
+			{ID: "cf-handler-aws", AWSAccount: "123456789012", AWSRegion: "us-east-1", Runtime: DefaultRuntime},
+			{ID: "cf-handler-aws-dev", AWSAccount: "123456789012", AWSRegion: "us-east-1", Runtime: DefaultRuntime},
+		},
+		Links: []Link{
+			{TargetGroup: "aws", Handler: "cf-handler-aws", Kind: "Account", Priority: 100},
+			{TargetGroup: "aws", Handler: "cf-handler-aws-dev", Kind: "Account", Priority: 100},
+		},
+	}
+
+	desired := current.Copy()
+	desired.RemoveHandler("cf-handler-aws-dev")
+	assert.Equal(t, []Change{
+		{Action: Delete, Link: &current.Links[1]},
+		{Action: Delete, Handler: &current.Handlers[1]},
+	}, Plan(desired, current))
+
+	desired.RemoveTargetGroup("aws")
+	assert.Empty(t, desired.Links)
+	assert.Len(t, desired.Handlers, 1)
+}
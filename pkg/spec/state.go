@@ -0,0 +1,61 @@
+package spec
+
+import (
+	"context"
+
+	"github.com/common-fate/glide-cli/pkg/client"
+	"github.com/common-fate/provider-registry-sdk-go/pkg/providerregistrysdk"
+	"github.com/pkg/errors"
+)
+
+// Current reads the Target Groups, Handlers and links which
+// are currently registered in Common Fate using the admin API.
+func Current(ctx context.Context, cf *client.Client) (*Spec, error) {
+	var s Spec
+
+	tgs, err := cf.AdminListTargetGroupsWithResponse(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "listing target groups")
+	}
+
+	for _, tg := range tgs.JSON200.TargetGroups {
+		s.TargetGroups = append(s.TargetGroups, TargetGroup{
+			ID: tg.Id,
+			Provider: providerregistrysdk.Provider{
+				Publisher: tg.From.Publisher,
+				Name:      tg.From.Name,
+				Version:   tg.From.Version,
+			}.String(),
+			Kind: tg.From.Kind,
+		})
+
+		routes, err := cf.AdminListTargetRoutesWithResponse(ctx, tg.Id)
+		if err != nil {
+			return nil, errors.Wrapf(err, "listing routes for target group %s", tg.Id)
+		}
+		for _, r := range routes.JSON200.Routes {
+			s.Links = append(s.Links, Link{
+				TargetGroup: r.TargetGroupId,
+				Handler:     r.HandlerId,
+				Kind:        r.Kind,
+				Priority:    r.Priority,
+			})
+		}
+	}
+
+	handlers, err := cf.AdminListHandlersWithResponse(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "listing handlers")
+	}
+
+	for _, h := range handlers.JSON200.Res {
+		s.Handlers = append(s.Handlers, Handler{
+			ID:         h.Id,
+			AWSAccount: h.AwsAccount,
+			AWSRegion:  h.AwsRegion,
+			Runtime:    h.Runtime,
+		})
+	}
+
+	return &s, nil
+}
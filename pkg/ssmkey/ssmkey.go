@@ -14,3 +14,8 @@ type SSMKeyOpts struct {
 func SSMKey(opts SSMKeyOpts) string {
 	return "/" + path.Join("common-fate", "provider", opts.Publisher, opts.ProviderName, opts.HandlerID, opts.Key)
 }
+
+// Prefix returns the path that the secrets for a Handler are stored under, with a trailing slash.
+func Prefix(publisher string, providerName string, handlerID string) string {
+	return "/" + path.Join("common-fate", "provider", publisher, providerName, handlerID) + "/"
+}
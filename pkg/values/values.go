@@ -0,0 +1,137 @@
+// Package values reads values files, which allow
+// 'cf provider deploy' to run without interactive prompts.
+package values
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+)
+
+// Values configures a provider deployment.
+//
+// An example values file is:
+//
+//	provider: common-fate/aws@v0.4.0
+//	handlerId: cf-handler-common-fate-aws
+//	targetGroupId: common-fate-aws
+//	commonFateAwsAccount: "123456789012"
+//	kind: Account
+//	config:
+//	  identityStoreRegion: us-east-1
+//	  apiToken:
+//	    fromEnv: API_TOKEN
+type Values struct {
+	Provider             string                 `yaml:"provider"`
+	HandlerID            string                 `yaml:"handlerId"`
+	TargetGroupID        string                 `yaml:"targetGroupId"`
+	CommonFateAWSAccount string                 `yaml:"commonFateAwsAccount"`
+	Kind                 string                 `yaml:"kind"`
+	Config               map[string]ConfigValue `yaml:"config"`
+	Secrets              Secrets                `yaml:"secrets"`
+}
+
+// Secrets configures where secret config values are stored.
+type Secrets struct {
+	// KMSKeyID is an optional customer-managed KMS key to encrypt secrets with.
+	KMSKeyID string `yaml:"kmsKeyId"`
+}
+
+// ConfigValue is a provider config value. It can be provided as a plain
+// string, or read from an environment variable or a file. Secrets can also
+// reference an existing AWS SSM parameter, so that the secret value never
+// needs to be handled by the CLI.
+//
+// Exactly one of the fields is set.
+type ConfigValue struct {
+	Value    string `yaml:"value"`
+	FromEnv  string `yaml:"fromEnv"`
+	FromFile string `yaml:"fromFile"`
+	FromSSM  string `yaml:"fromSSM"`
+}
+
+// UnmarshalYAML allows config values to be provided as plain strings.
+func (c *ConfigValue) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		c.Value = node.Value
+		return nil
+	}
+
+	// configValue prevents UnmarshalYAML from being called recursively.
+	type configValue ConfigValue
+	var v configValue
+	err := node.Decode(&v)
+	if err != nil {
+		return err
+	}
+
+	var sources int
+	for _, s := range []string{v.Value, v.FromEnv, v.FromFile, v.FromSSM} {
+		if s != "" {
+			sources++
+		}
+	}
+	if sources != 1 {
+		return fmt.Errorf("line %d: exactly one of 'value', 'fromEnv', 'fromFile' or 'fromSSM' must be set", node.Line)
+	}
+
+	*c = ConfigValue(v)
+	return nil
+}
+
+// Resolve returns the config value from its source.
+// Values referencing SSM parameters can't be resolved and return an error.
+func (c ConfigValue) Resolve() (string, error) {
+	switch {
+	case c.FromEnv != "":
+		val, ok := os.LookupEnv(c.FromEnv)
+		if !ok {
+			return "", fmt.Errorf("environment variable %s is not set", c.FromEnv)
+		}
+		return val, nil
+	case c.FromFile != "":
+		b, err := os.ReadFile(c.FromFile)
+		if err != nil {
+			return "", err
+		}
+		// files usually end with a trailing newline which isn't part of the value.
+		return strings.TrimRight(string(b), "\r\n"), nil
+	case c.FromSSM != "":
+		return "", fmt.Errorf("the value references the SSM parameter %s and can't be read directly", c.FromSSM)
+	default:
+		return c.Value, nil
+	}
+}
+
+// Load reads a values file. Files referenced with 'fromFile' are
+// resolved relative to the directory containing the values file.
+func Load(path string) (*Values, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var v Values
+	dec := yaml.NewDecoder(bytes.NewReader(b))
+	// catch typos in keys rather than silently ignoring them.
+	dec.KnownFields(true)
+	err = dec.Decode(&v)
+	if err != nil {
+		return nil, errors.Wrapf(err, "parsing %s", path)
+	}
+
+	dir := filepath.Dir(path)
+	for k, c := range v.Config {
+		if c.FromFile != "" && !filepath.IsAbs(c.FromFile) {
+			c.FromFile = filepath.Join(dir, c.FromFile)
+			v.Config[k] = c
+		}
+	}
+
+	return &v, nil
+}
@@ -0,0 +1,88 @@
+package values
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoad(t *testing.T) {
+	type testcase struct {
+		name    string
+		file    string
+		want    map[string]string
+		wantErr bool
+	}
+
+	testcases := []testcase{
+		{
+			name: "plain, env and file values",
+			file: `
+provider: common-fate/aws@v0.4.0
+config:
+  region: us-east-1
+  token:
+    fromEnv: TEST_VALUES_TOKEN
+  password:
+    fromFile: password.txt
+`,
+			want: map[string]string{
+				"region":   "us-east-1",
+				"token":    "token-from-env",
+				"password": "password-from-file",
+			},
+		},
+		{
+			name: "multiple sources",
+			file: `
+config:
+  token:
+    value: abc
+    fromEnv: TEST_VALUES_TOKEN
+`,
+			wantErr: true,
+		},
+		{
+			name:    "unknown key",
+			file:    "handlerID: cf-handler-aws",
+			wantErr: true,
+		},
+	}
+
+	t.Setenv("TEST_VALUES_TOKEN", "token-from-env")
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := t.TempDir()
+			err := os.WriteFile(filepath.Join(dir, "password.txt"), []byte("password-from-file\n"), 0600)
+			if err != nil {
+				t.Fatal(err)
+			}
+			path := filepath.Join(dir, "deploy.yaml")
+			err = os.WriteFile(path, []byte(tc.file), 0600)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			v, err := Load(path)
+			if tc.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got := map[string]string{}
+			for k, c := range v.Config {
+				got[k], err = c.Resolve()
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}